@@ -0,0 +1,91 @@
+package gosnowth
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Aggregate functions, which combine a set of values into a single value.
+const (
+	AggregateSum     = "sum"
+	AggregateAverage = "average"
+	AggregateCount   = "count"
+	AggregateMin     = "min"
+	AggregateMax     = "max"
+)
+
+// aggregateValues - combine values using the aggregate function named
+func aggregateValues(fn string, values []float64) (float64, error) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	switch fn {
+	case AggregateSum:
+		return sum, nil
+	case AggregateAverage:
+		if len(values) == 0 {
+			return 0, nil
+		}
+		return sum / float64(len(values)), nil
+	case AggregateCount:
+		return float64(len(values)), nil
+	case AggregateMin, AggregateMax:
+		if len(values) == 0 {
+			return 0, nil
+		}
+		m := values[0]
+		for _, v := range values[1:] {
+			if (fn == AggregateMin && v < m) || (fn == AggregateMax && v > m) {
+				m = v
+			}
+		}
+		return m, nil
+	}
+	return 0, fmt.Errorf("unknown aggregate function: %s", fn)
+}
+
+// AlignToGrid - align several series of NNT values to a common grid of
+// count buckets of the period given, in seconds, starting at the start
+// time, so that the series may be combined value by value.  Each value is
+// placed in the bucket containing its time, values outside of the grid are
+// dropped, and the Float values sharing a bucket are averaged.  Buckets
+// without a value are filled with NaN.  The aligned series are returned in
+// the order given.
+func AlignToGrid(series [][]NNTValue, start time.Time, period int64,
+	count int) [][]NNTValue {
+
+	result := make([][]NNTValue, len(series))
+	if period <= 0 || count <= 0 {
+		return result
+	}
+	span := time.Duration(period) * time.Second
+	for i, values := range series {
+		var (
+			sums   = make([]float64, count)
+			counts = make([]int, count)
+		)
+		for _, v := range values {
+			if v.Time.Before(start) {
+				continue
+			}
+			b := int(v.Time.Sub(start) / span)
+			if b >= count {
+				continue
+			}
+			sums[b] += v.Float
+			counts[b]++
+		}
+		aligned := make([]NNTValue, count)
+		for b := range aligned {
+			ts := start.Add(time.Duration(b) * span)
+			aligned[b] = newNNTValue(ts, math.NaN())
+			if n := counts[b]; n > 0 {
+				aligned[b].setFloat(sums[b] / float64(n))
+			}
+		}
+		result[i] = aligned
+	}
+	return result
+}
@@ -0,0 +1,42 @@
+package gosnowth
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlignToGrid(t *testing.T) {
+	aligned := AlignToGrid([][]NNTValue{
+		{
+			{Time: time.Unix(1380000000, 0), Float: 1},
+			{Time: time.Unix(1380000060, 0), Float: 2},
+			{Time: time.Unix(1380000120, 0), Float: 3},
+		},
+		{
+			{Time: time.Unix(1379999990, 0), Float: 9},
+			{Time: time.Unix(1380000010, 0), Float: 10},
+			{Time: time.Unix(1380000130, 0), Float: 30},
+			{Time: time.Unix(1380000150, 0), Float: 40},
+		},
+	}, time.Unix(1380000000, 0), 60, 3)
+
+	assert.Equal(t, 2, len(aligned))
+	for _, series := range aligned {
+		if assert.Equal(t, 3, len(series)) {
+			for b, v := range series {
+				assert.Equal(t, time.Unix(1380000000+60*int64(b), 0), v.Time,
+					"series should share the grid")
+			}
+		}
+	}
+	assert.Equal(t, []float64{1, 2, 3}, []float64{aligned[0][0].Float,
+		aligned[0][1].Float, aligned[0][2].Float})
+	assert.Equal(t, float64(10), aligned[1][0].Float)
+	assert.True(t, math.IsNaN(aligned[1][1].Float),
+		"missing buckets should be NaN")
+	assert.Equal(t, float64(35), aligned[1][2].Float,
+		"values sharing a bucket should be averaged")
+}
@@ -0,0 +1,46 @@
+package gosnowth
+
+import (
+	"io"
+	"time"
+)
+
+// SnowthAPI - the primitive state, topology and data operations of a
+// SnowthClient.  Code depending on this interface, rather than on a
+// SnowthClient, may be tested without a live cluster, by substituting a
+// fake such as the one in the snowthtest package.  The helpers built on top
+// of these operations, such as ReadAggregateByTags, are not included.
+type SnowthAPI interface {
+	ListActiveNodes() []*SnowthNode
+	ListInactiveNodes() []*SnowthNode
+
+	GetNodeState(node *SnowthNode) (*NodeState, error)
+	GetGossipInfo(node *SnowthNode) (*Gossip, error)
+	GetTopologyInfo(node *SnowthNode) (*Topology, error)
+	GetTopoRingInfo(hash string, node *SnowthNode) (*TopoRing, error)
+	LocateMetric(uuid string, metric string,
+		node *SnowthNode) (*DataLocation, error)
+	FindTags(node *SnowthNode, accountID int32, query string,
+		start, end string) ([]FindTagsItem, error)
+
+	WriteNNT(node *SnowthNode, data ...NNTData) error
+	WriteText(node *SnowthNode, data ...TextData) error
+	WriteHistogram(node *SnowthNode, data ...HistogramData) error
+	WriteRaw(node *SnowthNode, data io.Reader, fb bool,
+		dataPoints uint64) error
+
+	ReadNNTValues(node *SnowthNode, start, end time.Time, period int64,
+		t, id, metric string, opts ...ReadOption) ([]NNTValue, error)
+	ReadNNTAllValues(node *SnowthNode, start, end time.Time, period int64,
+		id, metric string, opts ...ReadOption) ([]NNTAllValue, error)
+	ReadTextValues(node *SnowthNode, start, end time.Time,
+		id, metric string, opts ...ReadOption) ([]TextValue, error)
+	ReadHistogramValues(node *SnowthNode, start, end time.Time, period int64,
+		id, metric string, opts ...ReadOption) ([]HistogramValue, error)
+	ReadRollupValues(node *SnowthNode, id, metric string, tags []string,
+		rollup time.Duration, start, end time.Time,
+		opts ...ReadOption) ([]RollupValues, error)
+}
+
+// SnowthClient implements the SnowthAPI.
+var _ SnowthAPI = (*SnowthClient)(nil)
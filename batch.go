@@ -0,0 +1,226 @@
+package gosnowth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultReadConcurrency - the number of reads of a batch made at once by
+// default
+const defaultReadConcurrency = 8
+
+// WithReadConcurrency - set the number of reads of a batch, read with
+// ReadNNTBatch, which are made at once.  The default is 8.
+func WithReadConcurrency(n int) ClientOption {
+	return func(sc *SnowthClient) error {
+		if n <= 0 {
+			return errors.New("read concurrency must be positive")
+		}
+		sc.readConcurrency = n
+		return nil
+	}
+}
+
+// NNTRequest - a read of NNT data in a batch, of the field named by the type,
+// such as "count" or "average", of a metric
+type NNTRequest struct {
+	ID     string
+	Metric string
+	Type   string
+}
+
+// NNTResult - the result of a read of NNT data in a batch, holding either
+// the values read or the error which failed the read
+type NNTResult struct {
+	Request NNTRequest
+	Values  []NNTValue
+	Err     error
+}
+
+// ReadNNTBatch - read NNT data for many metrics at once from a node, as
+// ReadNNTValues does for each.  The reads are made concurrently, bounded by
+// the concurrency set with WithReadConcurrency, and the results are in the
+// order of the requests.  A failed read is reported in its result, without
+// failing the other reads, and an error is only returned if every read
+// fails.
+func (sc *SnowthClient) ReadNNTBatch(node *SnowthNode, start, end time.Time,
+	period int64, requests []NNTRequest,
+	opts ...ReadOption) ([]NNTResult, error) {
+	return sc.ReadNNTBatchContext(context.Background(), node, start, end,
+		period, requests, opts...)
+}
+
+// ReadNNTBatchContext - read NNT data for a batch of metrics, as ReadNNTBatch
+// does, aborting the reads when the context given is done
+func (sc *SnowthClient) ReadNNTBatchContext(ctx context.Context,
+	node *SnowthNode, start, end time.Time, period int64,
+	requests []NNTRequest, opts ...ReadOption) ([]NNTResult, error) {
+
+	concurrency := sc.readConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultReadConcurrency
+	}
+	var (
+		results = make([]NNTResult, len(requests))
+		work    = make(chan int)
+		wg      sync.WaitGroup
+	)
+	for w := 0; w < concurrency && w < len(requests); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				r := requests[i]
+				values, err := sc.ReadNNTValuesContext(
+					ctx, node, start, end, period, r.Type,
+					r.ID, r.Metric, opts...)
+				results[i] = NNTResult{Request: r, Values: values, Err: err}
+			}
+		}()
+	}
+	for i := range requests {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	mErr := newMultiError()
+	for _, r := range results {
+		if r.Err == nil {
+			return results, nil
+		}
+		mErr.Add(errors.Wrapf(r.Err, "failed to read %s", r.Request.Metric))
+	}
+	if mErr.HasError() {
+		return results, mErr
+	}
+	return results, nil
+}
+
+// fetchPath - the path of the fetch endpoint of a node, which reads many
+// streams in a single request
+const fetchPath = "/fetch"
+
+// fetchStream - a stream of a fetch request, the values of a metric read
+// with a transform
+type fetchStream struct {
+	UUID      string `json:"uuid"`
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Label     string `json:"label"`
+	Transform string `json:"transform"`
+}
+
+// fetchReduce - a reduction of the streams of a fetch request
+type fetchReduce struct {
+	Label  string `json:"label"`
+	Method string `json:"method"`
+}
+
+// fetchRequest - the JSON form of a fetch request
+type fetchRequest struct {
+	Start   int64         `json:"start"`
+	Period  int64         `json:"period"`
+	Count   int64         `json:"count"`
+	Streams []fetchStream `json:"streams"`
+	Reduce  []fetchReduce `json:"reduce"`
+}
+
+// fetchResponse - the DF4 JSON form of a fetch result
+type fetchResponse struct {
+	Head struct {
+		Count  int   `json:"count"`
+		Start  int64 `json:"start"`
+		Period int64 `json:"period"`
+	} `json:"head"`
+	Data [][]*float64 `json:"data"`
+}
+
+// ReadNNTMulti - read NNT data for many metrics from a node in a single
+// request, rather than a request per metric as ReadNNTBatch makes, with the
+// aggregation given, such as "average" or "count".  The values read are
+// keyed by the metric they were read from.  Every metric asked for has an
+// entry, and a metric with no data in the window, including one the node
+// does not know, has no values, as an empty slice.  An unknown aggregation,
+// or a period which is not positive, returns an error without a request
+// being made.
+func (sc *SnowthClient) ReadNNTMulti(node *SnowthNode, start, end time.Time,
+	period int64, agg NNTAggregation,
+	metrics []MetricRef) (map[MetricRef][]NNTValue, error) {
+	return sc.ReadNNTMultiContext(context.Background(), node, start, end,
+		period, agg, metrics)
+}
+
+// ReadNNTMultiContext - read NNT data for several metrics at once, as
+// ReadNNTMulti does, aborting the read when the context given is done
+func (sc *SnowthClient) ReadNNTMultiContext(ctx context.Context,
+	node *SnowthNode, start, end time.Time, period int64,
+	agg NNTAggregation,
+	metrics []MetricRef) (map[MetricRef][]NNTValue, error) {
+
+	if err := checkPeriod(period); err != nil {
+		return nil, err
+	}
+	if !agg.Valid() {
+		return nil, fmt.Errorf("unknown nnt aggregation: %s", agg)
+	}
+	if end.Before(start) {
+		return nil, errors.New("end of read window is before its start")
+	}
+	result := make(map[MetricRef][]NNTValue, len(metrics))
+	if len(metrics) == 0 {
+		return result, nil
+	}
+
+	first := periodStart(start, period).Unix()
+	fr := fetchRequest{
+		Start:   first,
+		Period:  period,
+		Count:   (end.Unix()-first)/period + 1,
+		Streams: make([]fetchStream, 0, len(metrics)),
+		Reduce:  []fetchReduce{{Label: "pass", Method: "pass"}},
+	}
+	for _, ref := range metrics {
+		fr.Streams = append(fr.Streams, fetchStream{
+			UUID:      ref.ID,
+			Name:      sc.metricName(ref.Metric),
+			Kind:      "numeric",
+			Label:     ref.Metric,
+			Transform: string(agg),
+		})
+	}
+	buf := new(bytes.Buffer)
+	if err := json.NewEncoder(buf).Encode(fr); err != nil {
+		return nil, errors.Wrap(err, "failed to encode fetch request")
+	}
+
+	resp := new(fetchResponse)
+	err := sc.doContext(ctx, node, "POST", fetchPath, buf, resp,
+		decodeJSONFromResponse)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) != len(metrics) {
+		return nil, fmt.Errorf("fetch result has %d series for %d metrics",
+			len(resp.Data), len(metrics))
+	}
+	for i, data := range resp.Data {
+		values := []NNTValue{}
+		for j, v := range data {
+			if v == nil {
+				continue
+			}
+			ts := resp.Head.Start + int64(j)*resp.Head.Period
+			values = append(values,
+				newNNTValue(time.Unix(ts, 0), *v))
+		}
+		result[metrics[i]] = values
+	}
+	return result, nil
+}
@@ -0,0 +1,127 @@
+package gosnowth
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadNNTBatch(t *testing.T) {
+	var inFlight, maxInFlight int32
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		if strings.HasSuffix(r.URL.Path, "/bad") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		parts := strings.Split(r.URL.Path, "/")
+		w.Write([]byte("[[1380000000," + parts[len(parts)-1][1:] + "]]"))
+	})
+	defer ts.Close()
+	sc, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithReadConcurrency(2))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+	node := sc.ListActiveNodes()[0]
+
+	requests := []NNTRequest{
+		{ID: "id", Metric: "m1", Type: "count"},
+		{ID: "id", Metric: "bad", Type: "count"},
+		{ID: "id", Metric: "m3", Type: "count"},
+		{ID: "id", Metric: "m4", Type: "count"},
+		{ID: "id", Metric: "m5", Type: "count"},
+	}
+	results, err := sc.ReadNNTBatch(node, time.Unix(1380000000, 0),
+		time.Unix(1380000060, 0), 60, requests)
+	assert.NoError(t, err, "a partial failure should not fail the batch")
+	if assert.Equal(t, len(requests), len(results)) {
+		for i, r := range results {
+			assert.Equal(t, requests[i], r.Request,
+				"results should be in request order")
+			if r.Request.Metric == "bad" {
+				assert.Error(t, r.Err)
+				continue
+			}
+			assert.NoError(t, r.Err)
+			assert.Equal(t, float64(i+1), r.Values[0].Float)
+		}
+	}
+	assert.True(t, atomic.LoadInt32(&maxInFlight) <= 2,
+		"reads should be bounded by the concurrency")
+
+	_, err = sc.ReadNNTBatch(node, time.Unix(1380000000, 0),
+		time.Unix(1380000060, 0), 60, requests[1:2])
+	assert.Error(t, err, "a batch in which every read fails should fail")
+}
+
+func TestReadNNTMulti(t *testing.T) {
+	var requests int32
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/fetch", r.URL.Path)
+		var fr fetchRequest
+		if err := json.NewDecoder(r.Body).Decode(&fr); err != nil {
+			t.Error("error decoding fetch request: ", err)
+			return
+		}
+		assert.Equal(t, int64(1380000000), fr.Start)
+		assert.Equal(t, int64(60), fr.Period)
+		assert.Equal(t, int64(3), fr.Count)
+		assert.Equal(t, []fetchStream{
+			{UUID: "id1", Name: "m1", Kind: "numeric", Label: "m1",
+				Transform: "average"},
+			{UUID: "id2", Name: "m2", Kind: "numeric", Label: "m2",
+				Transform: "average"},
+		}, fr.Streams)
+		w.Write([]byte(`{"version":"DF4",
+			"head":{"count":3,"start":1380000000,"period":60},
+			"meta":[{"kind":"numeric","label":"pass"},
+				{"kind":"numeric","label":"pass"}],
+			"data":[[1.5,null,2],[null,null,null]]}`))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	defer sc.Close()
+
+	refs := []MetricRef{{ID: "id1", Metric: "m1"}, {ID: "id2", Metric: "m2"}}
+	result, err := sc.ReadNNTMulti(node, time.Unix(1380000030, 0),
+		time.Unix(1380000120, 0), 60, NNTAverage, refs)
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, map[MetricRef][]NNTValue{
+		refs[0]: {
+			{Time: time.Unix(1380000000, 0), Value: 1, Float: 1.5},
+			{Time: time.Unix(1380000120, 0), Value: 2, Float: 2},
+		},
+		refs[1]: {},
+	}, result, "a metric with no data should have no values")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests),
+		"the metrics should be read in a single request")
+
+	_, err = sc.ReadNNTMulti(node, time.Unix(1380000000, 0),
+		time.Unix(1380000120, 0), 60, "median", refs)
+	assert.Error(t, err, "an unknown aggregation should be rejected")
+	result, err = sc.ReadNNTMulti(node, time.Unix(1380000000, 0),
+		time.Unix(1380000120, 0), 60, NNTAverage, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests),
+		"no request should be made without metrics")
+}
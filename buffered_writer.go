@@ -0,0 +1,136 @@
+package gosnowth
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// BufferedWriter - buffers NNT data written through it, and writes it to
+// the cluster in batches.  When the buffer is flushed, the buffered data is
+// grouped by the node owning each metric, according to the topology ring in
+// use by the node the writer was created with, and each group is written to
+// its owning node.  If that node knows no topology, all data is written to
+// the first active node in the fallback node order set with
+// WithFallbackNodeOrder.  A BufferedWriter is safe for concurrent use.
+type BufferedWriter struct {
+	sc   *SnowthClient
+	node *SnowthNode
+	size int
+
+	mu  sync.Mutex
+	buf []NNTData
+}
+
+// NewBufferedWriter - create a buffered writer, which flushes whenever the
+// number of buffered data points reaches the size given.  The node given is
+// used to look up the topology ring, and receives data whose owner is not an
+// active node of the client.
+func (sc *SnowthClient) NewBufferedWriter(node *SnowthNode,
+	size int) *BufferedWriter {
+	if size <= 0 {
+		size = importBatchSize
+	}
+	return &BufferedWriter{sc: sc, node: node, size: size}
+}
+
+// WriteNNT - buffer NNT data, flushing the buffer if it is full
+func (bw *BufferedWriter) WriteNNT(data ...NNTData) error {
+	return bw.WriteNNTContext(context.Background(), data...)
+}
+
+// WriteNNTContext - buffer NNT data, as WriteNNT does, aborting any flush
+// when the context given is done
+func (bw *BufferedWriter) WriteNNTContext(ctx context.Context,
+	data ...NNTData) error {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	bw.buf = append(bw.buf, data...)
+	if len(bw.buf) >= bw.size {
+		return bw.flush(ctx)
+	}
+	return nil
+}
+
+// Flush - write all buffered data to the nodes owning it
+func (bw *BufferedWriter) Flush() error {
+	return bw.FlushContext(context.Background())
+}
+
+// FlushContext - write all buffered data to the nodes owning it, as Flush
+// does, aborting the writes when the context given is done
+func (bw *BufferedWriter) FlushContext(ctx context.Context) error {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	return bw.flush(ctx)
+}
+
+// flush - write the buffered data, grouped by owning node.  Data which
+// could not be written remains buffered.  When a node applies backpressure,
+// flushing stops, leaving the data not yet written buffered, and an error
+// wrapping ErrBackpressure is returned, so that the caller may slow down
+// before flushing again.
+func (bw *BufferedWriter) flush(ctx context.Context) error {
+	if len(bw.buf) == 0 {
+		return nil
+	}
+	ring, err := bw.sc.routingRing(ctx, bw.node)
+	if err != nil {
+		return err
+	}
+
+	var (
+		groups = make(map[*SnowthNode][]NNTData)
+		order  []*SnowthNode
+	)
+	for _, d := range bw.buf {
+		owner := bw.owner(ring, d.ID, d.Metric)
+		if _, ok := groups[owner]; !ok {
+			order = append(order, owner)
+		}
+		groups[owner] = append(groups[owner], d)
+	}
+
+	var (
+		mErr   = newMultiError()
+		failed []NNTData
+	)
+	for i, owner := range order {
+		err := bw.sc.WriteNNTContext(ctx, owner, groups[owner]...)
+		if err == nil {
+			continue
+		}
+		err = errors.Wrapf(err, "failed to write to %s", owner.GetURL().Host)
+		if errors.Is(err, ErrBackpressure) {
+			for _, o := range order[i:] {
+				failed = append(failed, groups[o]...)
+			}
+			bw.buf = failed
+			return err
+		}
+		mErr.Add(err)
+		failed = append(failed, groups[owner]...)
+	}
+	bw.buf = failed
+	if mErr.HasError() {
+		return mErr
+	}
+	return nil
+}
+
+// owner - the active node owning a metric, or the writer's node if the
+// owner is unknown or not active
+func (bw *BufferedWriter) owner(ring *metricRing,
+	id, metric string) *SnowthNode {
+	owners, err := ring.owners(id, bw.sc.metricName(metric))
+	if err != nil || len(owners) == 0 {
+		bw.sc.Logger.Warnf("unable to locate owner of %s: %v", metric, err)
+		return bw.node
+	}
+	if n := bw.sc.findActiveNode(owners[0]); n != nil {
+		return n
+	}
+	bw.sc.Logger.Warnf("owner of %s is not active: %s", metric, owners[0])
+	return bw.node
+}
@@ -0,0 +1,121 @@
+package gosnowth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBufferedWriterSharding(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		writes = make(map[string][]string)
+	)
+	newServer := func(identity string) *httptest.Server {
+		return newRingNodeTestServer(identity, 2,
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/write/nnt" {
+					return
+				}
+				var data []map[string]interface{}
+				if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+					t.Error("invalid write: ", err)
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				for _, d := range data {
+					writes[identity] = append(writes[identity],
+						d["metric"].(string))
+				}
+			})
+	}
+	var addrs []string
+	for _, id := range []string{
+		"aaaaaaaa-0000-0000-0000-000000000000",
+		"bbbbbbbb-0000-0000-0000-000000000000",
+		"cccccccc-0000-0000-0000-000000000000",
+	} {
+		ts := newServer(id)
+		defer ts.Close()
+		addrs = append(addrs, ts.URL)
+	}
+	sc, err := NewSnowthClient(false, addrs...)
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+
+	bw := sc.NewBufferedWriter(sc.ListActiveNodes()[0], 10)
+	for _, metric := range []string{"a", "b", "c", "d"} {
+		if err := bw.WriteNNT(NNTData{
+			ID: ringTestUUID, Metric: metric, Count: 1,
+		}); err != nil {
+			t.Fatal("error buffering write: ", err)
+		}
+	}
+	assert.Equal(t, 0, len(writes), "writes should be buffered")
+	if err := bw.Flush(); err != nil {
+		t.Fatal("error flushing writes: ", err)
+	}
+	for _, metrics := range writes {
+		sort.Strings(metrics)
+	}
+	assert.Equal(t, map[string][]string{
+		"aaaaaaaa-0000-0000-0000-000000000000": {"b", "d"},
+		"bbbbbbbb-0000-0000-0000-000000000000": {"a"},
+		"cccccccc-0000-0000-0000-000000000000": {"c"},
+	}, writes, "writes should be grouped by owning node")
+}
+
+func TestBufferedWriterBackpressure(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		overloaded = true
+		written    int
+	)
+	ts := newRingNodeTestServer("aaaaaaaa-0000-0000-0000-000000000000", 2,
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/write/nnt" {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if overloaded {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			var data []map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+				t.Error("invalid write: ", err)
+			}
+			written += len(data)
+		})
+	defer ts.Close()
+	sc, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithRetryPolicy(RetryPolicy{}))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+
+	bw := sc.NewBufferedWriter(sc.ListActiveNodes()[0], 10)
+	assert.NoError(t, bw.WriteNNT(
+		NNTData{ID: ringTestUUID, Metric: "a", Count: 1},
+		NNTData{ID: ringTestUUID, Metric: "b", Count: 1}))
+	err = bw.Flush()
+	assert.True(t, errors.Is(err, ErrBackpressure),
+		"backpressure should be reported: %v", err)
+	assert.Equal(t, 2, len(bw.buf), "data should remain buffered")
+
+	mu.Lock()
+	overloaded = false
+	mu.Unlock()
+	assert.NoError(t, bw.Flush())
+	assert.Equal(t, 2, written)
+	assert.Equal(t, 0, len(bw.buf))
+}
@@ -0,0 +1,139 @@
+package gosnowth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// caqlPath - the path of the CAQL extension of a node
+const caqlPath = "/extension/lua/public/caql_v1"
+
+// CAQLError - an error returned by a node for a CAQL query which it could
+// not compile or run, such as one with a syntax error, as reported in the
+// user_error of the response.  It wraps the SnowthError of the response,
+// and is distinct from failures of the node.
+type CAQLError struct {
+	Message string
+	err     *SnowthError
+}
+
+// Error - the description of the error
+func (ce *CAQLError) Error() string {
+	return "caql query failed: " + ce.Message
+}
+
+// Unwrap - the error response of the node
+func (ce *CAQLError) Unwrap() error {
+	return ce.err
+}
+
+// caqlErrorResponse - the JSON form of a CAQL error response body
+type caqlErrorResponse struct {
+	UserError struct {
+		Message string `json:"message"`
+	} `json:"user_error"`
+}
+
+// CAQLValue - a value of a CAQL result series at a point in time
+type CAQLValue struct {
+	Time  time.Time
+	Value float64
+}
+
+// CAQLSeries - a series of a CAQL result, with the label and tags the query
+// gave it.  Times at which the series has no value are omitted.
+type CAQLSeries struct {
+	Label  string
+	Tags   []string
+	Values []CAQLValue
+}
+
+// CAQLResult - the result of a CAQL query
+type CAQLResult struct {
+	Start  time.Time
+	Period int64
+	Series []CAQLSeries
+}
+
+// caqlResponse - the DF4 JSON form of a CAQL result
+type caqlResponse struct {
+	Head struct {
+		Count  int   `json:"count"`
+		Start  int64 `json:"start"`
+		Period int64 `json:"period"`
+	} `json:"head"`
+	Meta []struct {
+		Label string   `json:"label"`
+		Tags  []string `json:"tags"`
+	} `json:"meta"`
+	Data [][]*float64 `json:"data"`
+}
+
+// ExecuteCAQL - run a CAQL query over the window given, with the period
+// given in seconds, on a node, returning the series it produces.  A query
+// which the node fails to compile or run returns a CAQLError.
+func (sc *SnowthClient) ExecuteCAQL(node *SnowthNode, query string,
+	start, end time.Time, period int64) (*CAQLResult, error) {
+	return sc.ExecuteCAQLContext(context.Background(), node, query, start,
+		end, period)
+}
+
+// ExecuteCAQLContext - run a CAQL query on a node, as ExecuteCAQL does,
+// aborting the request when the context given is done
+func (sc *SnowthClient) ExecuteCAQLContext(ctx context.Context,
+	node *SnowthNode, query string, start, end time.Time,
+	period int64) (*CAQLResult, error) {
+
+	q := url.Values{}
+	q.Set("query", query)
+	q.Set("start", strconv.FormatInt(start.Unix(), 10))
+	q.Set("end", strconv.FormatInt(end.Unix(), 10))
+	q.Set("period", strconv.FormatInt(period, 10))
+	q.Set("format", "DF4")
+
+	cr := new(caqlResponse)
+	err := sc.doContext(ctx, node, "GET", caqlPath+"?"+q.Encode(), nil, cr,
+		decodeJSONFromResponse)
+	if err != nil {
+		if se, ok := err.(*SnowthError); ok {
+			var er caqlErrorResponse
+			if json.Unmarshal([]byte(se.Body), &er) == nil &&
+				er.UserError.Message != "" {
+				return nil, &CAQLError{Message: er.UserError.Message, err: se}
+			}
+		}
+		return nil, err
+	}
+	if len(cr.Meta) != len(cr.Data) {
+		return nil, fmt.Errorf("caql result has %d series and %d labels",
+			len(cr.Data), len(cr.Meta))
+	}
+
+	result := &CAQLResult{
+		Start:  time.Unix(cr.Head.Start, 0),
+		Period: cr.Head.Period,
+		Series: make([]CAQLSeries, 0, len(cr.Data)),
+	}
+	for i, data := range cr.Data {
+		series := CAQLSeries{
+			Label:  cr.Meta[i].Label,
+			Tags:   cr.Meta[i].Tags,
+			Values: []CAQLValue{},
+		}
+		for j, v := range data {
+			if v == nil {
+				continue
+			}
+			series.Values = append(series.Values, CAQLValue{
+				Time:  time.Unix(cr.Head.Start+int64(j)*cr.Head.Period, 0),
+				Value: *v,
+			})
+		}
+		result.Series = append(result.Series, series)
+	}
+	return result, nil
+}
@@ -0,0 +1,59 @@
+package gosnowth
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecuteCAQL(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/extension/lua/public/caql_v1", r.URL.Path)
+		q := r.URL.Query()
+		assert.Equal(t, "1380000000", q.Get("start"))
+		assert.Equal(t, "1380000120", q.Get("end"))
+		assert.Equal(t, "60", q.Get("period"))
+		assert.Equal(t, "DF4", q.Get("format"))
+		if q.Get("query") != "find('cpu')" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"success":false,"status":"error",` +
+				`"user_error":{"message":"unknown function"}}`))
+			return
+		}
+		w.Write([]byte(`{"version":"DF4",
+			"head":{"count":2,"start":1380000000,"period":60},
+			"meta":[{"kind":"numeric","label":"cpu","tags":["host:a"]}],
+			"data":[[1.5,null]]}`))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	result, err := sc.ExecuteCAQL(node, "find('cpu')",
+		time.Unix(1380000000, 0), time.Unix(1380000120, 0), 60)
+	if err != nil {
+		t.Fatal("error executing caql: ", err)
+	}
+	assert.Equal(t, &CAQLResult{
+		Start:  time.Unix(1380000000, 0),
+		Period: 60,
+		Series: []CAQLSeries{{
+			Label: "cpu",
+			Tags:  []string{"host:a"},
+			Values: []CAQLValue{
+				{Time: time.Unix(1380000000, 0), Value: 1.5},
+			},
+		}},
+	}, result)
+
+	_, err = sc.ExecuteCAQL(node, "nope()",
+		time.Unix(1380000000, 0), time.Unix(1380000120, 0), 60)
+	var ce *CAQLError
+	if assert.True(t, errors.As(err, &ce), "should be a CAQLError: %v", err) {
+		assert.Equal(t, "unknown function", ce.Message)
+		var se *SnowthError
+		assert.True(t, errors.As(err, &se), "should wrap the SnowthError")
+	}
+}
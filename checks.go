@@ -0,0 +1,139 @@
+package gosnowth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultCheckMetadataTTL - how long check metadata is cached by default
+const defaultCheckMetadataTTL = 5 * time.Minute
+
+// CheckMetadata - the metadata of a check, for resolving a check uuid to a
+// human-readable name
+type CheckMetadata struct {
+	UUID      string
+	Name      string
+	AccountID int32
+	// Metrics are the names of the metrics of the check, including their
+	// stream tags.
+	Metrics []string
+}
+
+// checkCacheKey - identifies a check within the check metadata cache
+type checkCacheKey struct {
+	accountID int32
+	uuid      string
+}
+
+// checkCacheEntry - cached check metadata, and when it expires
+type checkCacheEntry struct {
+	metadata CheckMetadata
+	expires  time.Time
+}
+
+// checkCache - a cache of check metadata, in which entries expire after a
+// time to live
+type checkCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[checkCacheKey]checkCacheEntry
+	now     func() time.Time
+}
+
+// newCheckCache - create an empty check metadata cache
+func newCheckCache(ttl time.Duration) *checkCache {
+	return &checkCache{
+		ttl:     ttl,
+		entries: make(map[checkCacheKey]checkCacheEntry),
+		now:     time.Now,
+	}
+}
+
+// clone - copy check metadata, so that the copy shares no slices with it
+func (md CheckMetadata) clone() CheckMetadata {
+	md.Metrics = append([]string(nil), md.Metrics...)
+	return md
+}
+
+// get - get a copy of unexpired metadata from the cache
+func (cc *checkCache) get(key checkCacheKey) (CheckMetadata, bool) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	e, ok := cc.entries[key]
+	if !ok || !cc.now().Before(e.expires) {
+		delete(cc.entries, key)
+		return CheckMetadata{}, false
+	}
+	return e.metadata.clone(), true
+}
+
+// put - add a copy of metadata to the cache
+func (cc *checkCache) put(key checkCacheKey, md CheckMetadata) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.entries[key] = checkCacheEntry{
+		metadata: md.clone(),
+		expires:  cc.now().Add(cc.ttl),
+	}
+}
+
+// WithCheckMetadataTTL - set how long check metadata fetched by
+// GetCheckMetadata is cached for
+func WithCheckMetadataTTL(ttl time.Duration) ClientOption {
+	return func(sc *SnowthClient) error {
+		sc.checks.ttl = ttl
+		return nil
+	}
+}
+
+// GetCheckMetadata - get the metadata of a check in an account.  Snowth
+// keeps the name of a check with the tags of its metrics, so the metadata is
+// found with a tag query for the check uuid.  Metadata is cached, so that
+// repeated lookups do not query the node, until the cache time to live set
+// by WithCheckMetadataTTL has passed.  ErrValueNotFound is returned if no
+// metric of the check is found.
+func (sc *SnowthClient) GetCheckMetadata(node *SnowthNode, accountID int32,
+	uuid string) (*CheckMetadata, error) {
+	return sc.GetCheckMetadataContext(context.Background(), node, accountID,
+		uuid)
+}
+
+// GetCheckMetadataContext - get the metadata of a check, as GetCheckMetadata
+// does, aborting the request when the context given is done
+func (sc *SnowthClient) GetCheckMetadataContext(ctx context.Context,
+	node *SnowthNode, accountID int32,
+	uuid string) (*CheckMetadata, error) {
+
+	key := checkCacheKey{accountID: accountID, uuid: uuid}
+	if md, ok := sc.checks.get(key); ok {
+		return &md, nil
+	}
+	items, err := sc.FindTagsContext(ctx, node, accountID,
+		"and(__check_uuid:"+uuid+")", "", "")
+	if err != nil {
+		return nil, err
+	}
+	var md *CheckMetadata
+	for _, item := range items {
+		if item.UUID != uuid {
+			continue
+		}
+		if md == nil {
+			md = &CheckMetadata{
+				UUID:      uuid,
+				Name:      item.CheckName,
+				AccountID: accountID,
+			}
+		}
+		md.Metrics = append(md.Metrics, item.MetricName)
+	}
+	if md == nil {
+		return nil, errors.Wrapf(ErrValueNotFound,
+			"no metadata for check %s", uuid)
+	}
+	sc.checks.put(key, *md)
+	return md, nil
+}
@@ -0,0 +1,66 @@
+package gosnowth
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCheckMetadata(t *testing.T) {
+	const uuid = "fc85e0ab-f568-45e6-86ee-d7443be8277d"
+	var finds int
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		finds++
+		if r.URL.Query().Get("query") == "and(__check_uuid:"+uuid+")" {
+			w.Write([]byte(`[{"uuid":"` + uuid + `",` +
+				`"check_name":"api latency","metric_name":"latency"}]`))
+			return
+		}
+		w.Write([]byte("[]"))
+	})
+	defer ts.Close()
+	sc, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithCheckMetadataTTL(time.Minute))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	node := sc.ListActiveNodes()[0]
+	now := time.Now()
+	sc.checks.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		md, err := sc.GetCheckMetadata(node, 1, uuid)
+		if err != nil {
+			t.Fatal("error getting check metadata: ", err)
+		}
+		assert.Equal(t, "api latency", md.Name)
+	}
+	assert.Equal(t, 1, finds, "repeated lookups should be cached")
+
+	now = now.Add(2 * time.Minute)
+	if _, err := sc.GetCheckMetadata(node, 1, uuid); err != nil {
+		t.Fatal("error getting check metadata: ", err)
+	}
+	assert.Equal(t, 2, finds, "expired metadata should be fetched again")
+
+	md, err := sc.GetCheckMetadata(node, 1, uuid)
+	if err != nil {
+		t.Fatal("error getting check metadata: ", err)
+	}
+	md.Name = "changed"
+	md.Metrics[0] = "changed"
+	md, err = sc.GetCheckMetadata(node, 1, uuid)
+	if err != nil {
+		t.Fatal("error getting check metadata: ", err)
+	}
+	assert.Equal(t, "api latency", md.Name,
+		"changes to returned metadata should not affect the cache")
+	assert.Equal(t, []string{"latency"}, md.Metrics,
+		"changes to returned metrics should not affect the cache")
+
+	_, err = sc.GetCheckMetadata(node, 1, "unknown")
+	assert.Equal(t, ErrValueNotFound, errors.Cause(err))
+}
@@ -1,18 +1,18 @@
 package gosnowth
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
-	"os"
 	"strings"
 	"sync"
 	"time"
 
-	log "github.com/labstack/gommon/log"
-
 	"github.com/pkg/errors"
 )
 
@@ -23,6 +23,9 @@ import (
 // within.  A topology is a set of nodes that distribute data amoungst each other.
 
 type SnowthNode struct {
+	// mu guards the url and current topology, which discovery updates while
+	// the node is in use.
+	mu              sync.RWMutex
 	url             *url.URL
 	identifier      string
 	currentTopology string
@@ -32,15 +35,33 @@ type SnowthNode struct {
 // useful if you need the raw connection string of a given snowthnode, such as in
 // the event you are making a proxy for a snowth node.
 func (sn *SnowthNode) GetURL() *url.URL {
+	sn.mu.RLock()
+	defer sn.mu.RUnlock()
 	return sn.url
 }
 
 // GetCurrentTopology - This will return the hash string representation of the
 // node's current topology.
 func (sn *SnowthNode) GetCurrentTopology() string {
+	sn.mu.RLock()
+	defer sn.mu.RUnlock()
 	return sn.currentTopology
 }
 
+// setURL - set the url of the node
+func (sn *SnowthNode) setURL(u *url.URL) {
+	sn.mu.Lock()
+	defer sn.mu.Unlock()
+	sn.url = u
+}
+
+// setCurrentTopology - set the hash of the node's current topology
+func (sn *SnowthNode) setCurrentTopology(hash string) {
+	sn.mu.Lock()
+	defer sn.mu.Unlock()
+	sn.currentTopology = hash
+}
+
 // httpClient - interface in order to mock http requests
 type httpClient interface {
 	Do(req *http.Request) (*http.Response, error)
@@ -72,7 +93,8 @@ type httpClient interface {
 // from the nodes.
 //		6.) Lua Extensions APIs
 type SnowthClient struct {
-	c httpClient
+	c     httpClient
+	conns *connTracker
 
 	// in order to keep track of healthy nodes within the cluster,
 	// we have two lists of SnowthNode types, active and inactive.
@@ -85,7 +107,107 @@ type SnowthClient struct {
 	// watchInterval is the duration between checks to tell if a node is active
 	// or inactive.
 	watchInterval time.Duration
-	Logger        *log.Logger
+
+	// Logger receives the client's diagnostics.  It was formerly a
+	// *log.Logger of github.com/labstack/gommon/log, which the default
+	// logger still is, so that code setting the level of the default logger
+	// may assert its type, as sc.Logger.(*log.Logger).SetLevel(log.DEBUG).
+	Logger Logger
+
+	// watchJitter is the fraction of the watch interval by which each
+	// interval is randomly varied.
+	watchJitter float64
+
+	// gossipAgeThreshold is the gossip age, in seconds, beyond which a node
+	// is not active.
+	gossipAgeThreshold float64
+
+	// startupTimeout, when positive, bounds the time spent contacting the
+	// seed nodes and discovering their peers when the client is created.
+	startupTimeout time.Duration
+
+	// done is closed to stop the watch loop, which closes stopped as it
+	// exits.
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+
+	// signer, when set, signs each request before it is sent.
+	signer RequestSigner
+
+	// compression is whether request and response bodies are compressed.
+	compression bool
+
+	// headerFuncs return the headers set on each request.
+	headerFuncs []HeaderFunc
+
+	// selector orders the nodes tried by failover reads.
+	selector Selector
+
+	// localAddr, when set, is the source address of requests made by the
+	// default http client.
+	localAddr net.Addr
+
+	// maxConnAge, when positive, is the age at which the default http
+	// client recycles connections.
+	maxConnAge time.Duration
+
+	// maxIdleConnsPerHost is the number of idle connections to each node
+	// kept by the default http client.
+	maxIdleConnsPerHost int
+
+	// limiter, when set, limits the rate of requests to all nodes.
+	limiter *rateLimiter
+
+	// dryRun, when set, receives the requests which would modify data in
+	// place of them being sent.
+	dryRun func(DryRunRequest)
+
+	// checks caches check metadata for name resolution.
+	checks *checkCache
+
+	// liveness, when set, is checked in deciding whether a node is active,
+	// in place of the gossip age check if livenessOnly is set.
+	liveness     LivenessCheck
+	livenessOnly bool
+
+	// retryableCodes are the response status codes on which data requests
+	// fail over to another node.
+	retryableCodes map[int]bool
+
+	// fallbackOrder is the order of the nodes used for routing when no
+	// topology is known.
+	fallbackOrder []string
+
+	// retry is the policy for retrying requests which failed transiently.
+	retry RetryPolicy
+
+	// readConcurrency is the number of reads of a batch made at once.
+	readConcurrency int
+
+	// observer receives an observation of each request made.
+	observer Observer
+
+	// discoverMu serializes the discovery of nodes.
+	discoverMu sync.Mutex
+
+	// noDiscovery is whether the client only uses its seed nodes.
+	noDiscovery bool
+
+	// topology is the topology last fetched in discovering nodes.
+	topologyMu sync.Mutex
+	topology   *Topology
+
+	// rings are the topology rings fetched by the client, by hash.
+	ringsMu sync.Mutex
+	rings   map[string]*metricRing
+
+	// metricPrefix is prepended to the names of the metrics of the client.
+	metricPrefix string
+
+	// checks of the nodes made by the watch loop, for ClusterHealth.
+	healthMu sync.Mutex
+	health   map[*SnowthNode]nodeCheck
 }
 
 // NewSnowthClient - given a variadic addrs parameter, the client will
@@ -94,55 +216,93 @@ type SnowthClient struct {
 // The discover parameter when true will allow the client to discover new
 // nodes from the topology
 func NewSnowthClient(discover bool, addrs ...string) (*SnowthClient, error) {
-	timeout := time.Duration(10 * time.Second)
-	client := &http.Client{
-		Timeout: timeout,
-	}
+	return NewSnowthClientWithOptions(discover, addrs)
+}
 
+// NewSnowthClientWithOptions - construct a client as NewSnowthClient does,
+// applying the client options given before any node is contacted.  When a
+// startup timeout is set with WithStartupTimeout, construction returns once
+// it elapses, and may then succeed with only some of the seed nodes, and a
+// partial topology, known to the client.
+func NewSnowthClientWithOptions(discover bool, addrs []string,
+	opts ...ClientOption) (*SnowthClient, error) {
 	sc := &SnowthClient{
-		c:               client,
+		conns:           newConnTracker(),
+		checks:          newCheckCache(defaultCheckMetadataTTL),
+		health:          make(map[*SnowthNode]nodeCheck),
+		retryableCodes:  statusCodeSet(defaultRetryableStatusCodes),
+		retry:           defaultRetryPolicy,
 		activeNodesMu:   new(sync.RWMutex),
 		activeNodes:     []*SnowthNode{},
 		inactiveNodesMu: new(sync.RWMutex),
 		inactiveNodes:   []*SnowthNode{},
 		watchInterval:   5 * time.Second,
-		Logger:          log.New("gosnowth"),
+		done:            make(chan struct{}),
+		stopped:         make(chan struct{}),
+
+		gossipAgeThreshold: defaultGossipAgeThreshold,
+		readConcurrency:    defaultReadConcurrency,
+		observer:           noopObserver{},
+
+		maxIdleConnsPerHost: defaultMaxIdleConnsPerHost,
+		selector:            NewRoundRobinSelector(),
 	}
 
-	var logLevel = os.Getenv("GOSNOWTH_LOGLEVEL")
-	switch strings.ToLower(logLevel) {
-	case "debug":
-		sc.Logger.SetLevel(log.DEBUG)
-	case "info":
-		sc.Logger.SetLevel(log.INFO)
-	case "warn":
-		sc.Logger.SetLevel(log.WARN)
-	case "error":
-		sc.Logger.SetLevel(log.ERROR)
-	default:
-		sc.Logger.SetLevel(log.OFF)
+	sc.Logger = newDefaultLogger()
+
+	for _, opt := range opts {
+		if err := opt(sc); err != nil {
+			return nil, errors.Wrap(err, "failed to apply client option")
+		}
+	}
+
+	if sc.c == nil {
+		sc.c = &http.Client{
+			Timeout: defaultHTTPTimeout,
+			Transport: newTransport(sc.conns, sc.localAddr, sc.maxConnAge,
+				sc.maxIdleConnsPerHost),
+		}
+	}
+
+	ctx := context.Background()
+	if sc.startupTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, sc.startupTimeout)
+		defer cancel()
 	}
 
 	// for each of the addrs we need to parse the connection string,
 	// then create a node for that connection string, poll the state
 	// of that node, and populate the identifier and topology of that
 	// node.  Finally we will add the node and activate it.
-	sc.Logger.Info("initializing snowth client")
+	sc.Logger.Infof("initializing snowth client")
 	numActiveNodes := 0
+	bootErr := &BootstrapError{}
 	for _, addr := range addrs {
 		url, err := url.Parse(addr)
+		if err == nil && (url.Scheme == "" || url.Host == "") {
+			err = errors.New("address is not an absolute url")
+		}
 		if err != nil {
 			// this node had an error, put on inactive list
-			log.Warnf("failed to bootstrap state of node: %+v", err)
+			sc.Logger.Errorf("failed to bootstrap state of node: %+v", err)
+			bootErr.Seeds = append(bootErr.Seeds, SeedError{
+				Addr: addr,
+				Err:  errors.Wrap(err, "invalid seed address"),
+			})
 			continue
 		}
 		sc.Logger.Debugf("creating snowth node: %s", addr)
 		node := &SnowthNode{url: url}
 		// call get state to populate the id of this node
-		state, err := sc.GetNodeState(node)
+		state, err := sc.GetNodeStateContext(ctx, node)
 		if err != nil {
 			// this node had an error, put on inactive list
-			log.Printf("failed to bootstrap state of node: %+v", err)
+			sc.Logger.Errorf("failed to bootstrap state of node: %+v", err)
+			bootErr.Seeds = append(bootErr.Seeds, SeedError{
+				Addr: addr,
+				Err:  errors.Wrap(err, "failed to get node state"),
+			})
 			continue
 		}
 		sc.Logger.Debugf("checked state of node: %s -> %s", addr, state.Identity)
@@ -155,20 +315,21 @@ func NewSnowthClient(discover bool, addrs ...string) (*SnowthClient, error) {
 	}
 
 	if numActiveNodes == 0 {
-		return nil, errors.New("No snowth nodes could be activated")
+		return nil, bootErr
 	}
 
 	// start a goroutine to watch for changes in state of the nodes,
 	// and manage the active/inactive lists accordingly
 	go sc.watchAndUpdate()
 
-	if discover {
-		sc.Logger.Debug("starting discovery of new nodes in topology")
+	if discover && !sc.noDiscovery {
+		sc.Logger.Debugf("starting discovery of new nodes in topology")
 		// for robustness, we will perform a discovery of associated nodes
 		// this works by pulling the topology information for given nodes
 		// and adding nodes discovered within the topology into the client
-		if err := sc.discoverNodes(); err != nil {
-			log.Errorf("failed to perform discovery of new nodes")
+		if err := sc.discoverNodesContext(ctx); err != nil {
+			sc.Logger.Errorf("failed to perform discovery of new nodes: %v",
+				err)
 		}
 	}
 
@@ -178,71 +339,163 @@ func NewSnowthClient(discover bool, addrs ...string) (*SnowthClient, error) {
 // isNodeActive - The check to see if a given node is active or not.
 // this will take into account ability to get the node state, gossip
 // information as well as the gossip age of the node.  If the age is
-// larger than 10 we will not consider this node active.
+// larger than the threshold set with WithGossipAgeThreshold, 10 seconds by
+// default, we will not consider this node active.  A liveness check
+// set with WithLivenessCheck is made in addition to, or in place of, these.
+// The outcome is recorded for ClusterHealth.
 func (sc *SnowthClient) isNodeActive(node *SnowthNode) bool {
+	age, err := sc.checkNode(node)
+	sc.recordCheck(node, age, err)
+	if err != nil {
+		sc.Logger.Warnf("%s", err.Error())
+		return false
+	}
+	return true
+}
+
+// checkNode - check whether a node is active, returning the gossip age
+// observed for it, if any, and the reason it is not active, if it is not
+func (sc *SnowthClient) checkNode(node *SnowthNode) (*float64, error) {
+	if sc.liveness != nil {
+		if !sc.liveness(node) {
+			return nil, errors.Errorf("liveness check failed: %s",
+				node.GetURL().Host)
+		}
+		if sc.livenessOnly {
+			return nil, nil
+		}
+	}
 	var id = node.identifier
 	if id == "" {
 		// go get state to figure out identity
 		state, err := sc.GetNodeState(node)
 		if err != nil {
 			// error means we failed, node is not active
-			sc.Logger.Warnf("unable to get the state of the node: %s", err.Error())
-			return false
+			return nil, errors.Wrap(err, "unable to get the state of the node")
 		}
-		sc.Logger.Debugf("retrieved state of node: %s -> %s", node.GetURL().Host, state.Identity)
+		sc.Logger.Debugf("retrieved state of node: %s -> %s",
+			node.GetURL().Host, state.Identity)
 		id = state.Identity
 	}
-	gossip, err := sc.GetGossipInfo(node)
+	gossip, err := sc.GetGossip(node)
 	if err != nil {
-		sc.Logger.Warnf("unable to get the gossip info of the node: %s", err.Error())
-		return false
+		return nil, errors.Wrap(err, "unable to get the gossip info of the node")
 	}
 	var age float64 = 100.0
-	for _, entry := range []GossipDetail(*gossip) {
+	for _, entry := range gossip {
 		if entry.ID == id {
 			age = entry.Age
 			break
 		}
 	}
-	if age > 10.0 {
-		sc.Logger.Warnf("gossip age expired: %s -> %d", node.GetURL().Host, age)
-		return false
+	if age > sc.gossipAgeThreshold {
+		return &age, errors.Errorf("gossip age expired: %s -> %f",
+			node.GetURL().Host, age)
 	}
-	return true
+	return &age, nil
 }
 
-// watchAndUpdate - watch gossip data for all nodes, and move the nodes to active
-// or inactive as required.  Will walk through the inactive nodes, checking for
-// aliveness, then walk through active nodes checking for aliveness.
+// watchAndUpdate - watch gossip data for all nodes, and move the nodes to
+// active or inactive as required.  Will walk through the inactive nodes,
+// checking for aliveness, then walk through active nodes checking for
+// aliveness.
 func (sc *SnowthClient) watchAndUpdate() {
-	for {
-		<-time.After(sc.watchInterval)
-		sc.Logger.Debug("firing watch and update")
+	defer close(sc.stopped)
+	for first := true; ; first = false {
+		timer := time.NewTimer(sc.nextWatchInterval(first))
+		select {
+		case <-sc.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		sc.Logger.Debugf("firing watch and update")
 		for _, node := range sc.ListInactiveNodes() {
-			sc.Logger.Debugf("checking node for inactive -> active: %s", node.GetURL().Host)
+			sc.Logger.Debugf("checking node for inactive -> active: %s",
+				node.GetURL().Host)
 			if sc.isNodeActive(node) {
 				// move to active
-				sc.Logger.Debugf("active, moving to active list: %s", node.GetURL().Host)
+				sc.Logger.Debugf("active, moving to active list: %s",
+					node.GetURL().Host)
 				sc.ActivateNodes(node)
 			}
 		}
 		for _, node := range sc.ListActiveNodes() {
-			sc.Logger.Debugf("checking node for active -> inactive: %s", node.GetURL().Host)
+			sc.Logger.Debugf("checking node for active -> inactive: %s",
+				node.GetURL().Host)
 			if !sc.isNodeActive(node) {
 				// move to active
-				sc.Logger.Warnf("inactive, moving to inactive list: %s", node.GetURL().Host)
+				sc.Logger.Warnf("inactive, moving to inactive list: %s",
+					node.GetURL().Host)
 				sc.DeactivateNodes(node)
 			}
 		}
 	}
 }
 
+// Close - stop the client from watching its nodes, waiting for the watch
+// loop to exit, and close the idle connections of the client's http client.
+// Clients should be closed once they are no longer needed, so that they may
+// be released.  Closing a client more than once has no effect.
+func (sc *SnowthClient) Close() {
+	sc.closeOnce.Do(func() {
+		close(sc.done)
+		<-sc.stopped
+		if c, ok := sc.c.(interface{ CloseIdleConnections() }); ok {
+			c.CloseIdleConnections()
+		}
+	})
+}
+
 // discoverNodes - private method for the client to discover peer nodes
 // related to the topology.  This function will go through the active nodes
 // get the topology information which shows all other nodes included in
 // the topology, and adds them as snowth nodes to this client's active pool
 // of nodesh
 func (sc *SnowthClient) discoverNodes() error {
+	return sc.discoverNodesContext(context.Background())
+}
+
+// WithoutDiscovery - use only the seed nodes given to the client, such as
+// when the nodes are reached through a fixed proxy or virtual IP, and the
+// addresses of the nodes in the topology cannot be reached.  Nodes are not
+// discovered when the client is created, whatever its discover parameter,
+// and later discovery, with Discover or ReloadTopology, caches the topology
+// without adding its nodes or changing the addresses of the seed nodes.
+// Requests routed to the owners of metrics, such as with NodesForMetric, are
+// routed to the seed nodes, in the fallback node order, as when no topology
+// is known.
+func WithoutDiscovery() ClientOption {
+	return func(sc *SnowthClient) error {
+		sc.noDiscovery = true
+		return nil
+	}
+}
+
+// Discover - discover the nodes of the cluster now, such as after nodes have
+// been added to or removed from it, rather than waiting for them to be found.
+// The topology of each active node is fetched, updating the addresses of
+// known nodes, adding any new ones, and caching the topology returned by
+// CurrentTopology.  It is safe to call while the client is watching its
+// nodes, and concurrently, with discoveries made one at a time.  Use
+// ReloadTopology when the cluster has activated a new topology.
+func (sc *SnowthClient) Discover() error {
+	return sc.DiscoverContext(context.Background())
+}
+
+// DiscoverContext - discover the nodes of the cluster, as Discover does,
+// aborting the requests when the context given is done
+func (sc *SnowthClient) DiscoverContext(ctx context.Context) error {
+	return sc.discoverNodesContext(ctx)
+}
+
+// discoverNodesContext - discover peer nodes, as discoverNodes does,
+// stopping when the context given is done, with the nodes discovered so far
+// kept by the client
+func (sc *SnowthClient) discoverNodesContext(ctx context.Context) error {
+	sc.discoverMu.Lock()
+	defer sc.discoverMu.Unlock()
+
 	// take our list of active nodes, interrogate gossipinfo
 	// get more nodes from the gossip info
 	var (
@@ -250,17 +503,29 @@ func (sc *SnowthClient) discoverNodes() error {
 		mErr    = newMultiError()
 	)
 	for _, node := range sc.ListActiveNodes() {
+		if ctx.Err() != nil {
+			mErr.Add(errors.Wrap(ctx.Err(), "discovery stopped early"))
+			break
+		}
 		// lookup the topology
-		topology, err := sc.GetTopologyInfo(node)
+		topology, err := sc.GetTopologyInfoContext(ctx, node)
 		if err != nil {
 			mErr.Add(errors.Wrap(err, "error getting topology info: %+v"))
 			continue
 		}
 
-		// populate all the nodes with the appropriate topology information
-		for _, topoNode := range topology.Nodes {
-			sc.populateNodeInfo(node.GetCurrentTopology(), topoNode)
+		// populate all the nodes with the appropriate topology information,
+		// contacting them with the scheme and base path of the node which
+		// reported them, unless only the seed nodes are used
+		if !sc.noDiscovery {
+			for _, topoNode := range topology.Nodes {
+				sc.populateNodeInfo(node.GetCurrentTopology(),
+					node.GetURL(), topoNode)
+			}
 		}
+		sc.topologyMu.Lock()
+		sc.topology = topology
+		sc.topologyMu.Unlock()
 		success = true
 	}
 
@@ -273,46 +538,40 @@ func (sc *SnowthClient) discoverNodes() error {
 }
 
 // populateNodeInfo - this helper method populates an existing node with the
-// details from the topology.  If a node doesn't exist, it will be added
-// to the list of active nodes in the client.
-func (sc *SnowthClient) populateNodeInfo(hash string, topology TopologyNode) {
+// details from the topology, to be contacted with the URL scheme and base
+// path of the seed URL given, or over http at the root if it is nil.  If a
+// node doesn't exist, it will be added to the list of active nodes in the
+// client.
+func (sc *SnowthClient) populateNodeInfo(hash string, seed *url.URL,
+	topology TopologyNode) {
 	var found = false
 
 	sc.activeNodesMu.Lock()
 	for i := 0; i < len(sc.activeNodes); i++ {
 		if sc.activeNodes[i].identifier == topology.ID {
 			found = true
-			url := url.URL{
-				Scheme: "http",
-				Host:   fmt.Sprintf("%s:%d", topology.Address, topology.APIPort),
-			}
-			sc.activeNodes[i].url = &url
-			sc.activeNodes[i].currentTopology = hash
+			sc.activeNodes[i].setURL(nodeURL(seed, topology.Address,
+				topology.APIPort))
+			sc.activeNodes[i].setCurrentTopology(hash)
 			continue
 		}
 	}
 	sc.activeNodesMu.Unlock()
 	sc.inactiveNodesMu.Lock()
 	for i := 0; i < len(sc.inactiveNodes); i++ {
-		found = true
 		if sc.inactiveNodes[i].identifier == topology.ID {
-			url := url.URL{
-				Scheme: "http",
-				Host:   fmt.Sprintf("%s:%d", topology.Address, topology.APIPort),
-			}
-			sc.inactiveNodes[i].url = &url
-			sc.inactiveNodes[i].currentTopology = hash
+			found = true
+			sc.inactiveNodes[i].setURL(nodeURL(seed, topology.Address,
+				topology.APIPort))
+			sc.inactiveNodes[i].setCurrentTopology(hash)
 			continue
 		}
 	}
 	sc.inactiveNodesMu.Unlock()
 	if !found {
 		newNode := &SnowthNode{
-			identifier: topology.ID,
-			url: &url.URL{
-				Scheme: "http",
-				Host:   fmt.Sprintf("%s:%d", topology.Address, topology.APIPort),
-			},
+			identifier:      topology.ID,
+			url:             nodeURL(seed, topology.Address, topology.APIPort),
 			currentTopology: hash,
 		}
 		sc.AddNodes(newNode)
@@ -320,6 +579,24 @@ func (sc *SnowthClient) populateNodeInfo(hash string, topology TopologyNode) {
 	}
 }
 
+// nodeURL - the URL of a node at the address and API port given, with the
+// scheme and base path of the seed URL given, or over http at the root if it
+// is nil
+func nodeURL(seed *url.URL, address string, port uint16) *url.URL {
+	u := &url.URL{
+		Scheme: "http",
+		Host:   fmt.Sprintf("%s:%d", address, port),
+	}
+	if seed != nil {
+		if seed.Scheme != "" {
+			u.Scheme = seed.Scheme
+		}
+		u.Path = seed.Path
+		u.RawPath = seed.RawPath
+	}
+	return u
+}
+
 // doChangeActivation - perform an activation state change
 func (sc *SnowthClient) doChangeActivation(from, to *[]*SnowthNode, nodes []*SnowthNode) {
 	sc.activeNodesMu.Lock()
@@ -341,11 +618,19 @@ func (sc *SnowthClient) DeactivateNodes(nodes ...*SnowthNode) {
 	sc.doChangeActivation(&sc.activeNodes, &sc.inactiveNodes, nodes)
 }
 
-// AddNodes - add nodes parameters to the inactive node list
+// AddNodes - add nodes parameters to the inactive node list.  Nodes already
+// known to the client, active or inactive, by identifier are not added again.
 func (sc *SnowthClient) AddNodes(nodes ...*SnowthNode) {
+	sc.activeNodesMu.RLock()
+	defer sc.activeNodesMu.RUnlock()
 	sc.inactiveNodesMu.Lock()
 	defer sc.inactiveNodesMu.Unlock()
-	sc.inactiveNodes = append(sc.inactiveNodes, nodes...)
+	for _, node := range nodes {
+		if hasNode(sc.activeNodes, node) || hasNode(sc.inactiveNodes, node) {
+			continue
+		}
+		sc.inactiveNodes = append(sc.inactiveNodes, node)
+	}
 }
 
 // doListNodes - helper to list the nodes, active or inactive
@@ -369,44 +654,386 @@ func (sc *SnowthClient) ListActiveNodes() []*SnowthNode {
 	return doListNodes(&sc.activeNodes, sc.activeNodesMu)
 }
 
+// SnowthNodeInfo - a copy of the metadata of a node, taken at a point in
+// time, which is safe to read while the client updates its nodes
+type SnowthNodeInfo struct {
+	ID              string
+	URL             string
+	CurrentTopology string
+	Active          bool
+}
+
+// ListActiveNodesSnapshot - list copies of the metadata of all of the
+// currently active nodes.  Unlike the nodes returned by ListActiveNodes, the
+// copies may be read without racing against discovery updating the nodes.
+func (sc *SnowthClient) ListActiveNodesSnapshot() []SnowthNodeInfo {
+	sc.activeNodesMu.RLock()
+	defer sc.activeNodesMu.RUnlock()
+	result := make([]SnowthNodeInfo, 0, len(sc.activeNodes))
+	return appendNodeInfo(result, sc.activeNodes, true)
+}
+
+// ListNodesSnapshot - list copies of the metadata of every node known to the
+// client, the active nodes followed by the inactive nodes, as
+// ListActiveNodesSnapshot does for the active nodes.  Both lists are read at
+// once, so that a node moving between them is listed exactly once.
+func (sc *SnowthClient) ListNodesSnapshot() []SnowthNodeInfo {
+	sc.activeNodesMu.RLock()
+	defer sc.activeNodesMu.RUnlock()
+	sc.inactiveNodesMu.RLock()
+	defer sc.inactiveNodesMu.RUnlock()
+	result := make([]SnowthNodeInfo, 0,
+		len(sc.activeNodes)+len(sc.inactiveNodes))
+	result = appendNodeInfo(result, sc.activeNodes, true)
+	return appendNodeInfo(result, sc.inactiveNodes, false)
+}
+
+// appendNodeInfo - append copies of the metadata of the nodes given.
+func appendNodeInfo(result []SnowthNodeInfo, nodes []*SnowthNode,
+	active bool) []SnowthNodeInfo {
+	for _, node := range nodes {
+		result = append(result, SnowthNodeInfo{
+			ID:              node.identifier,
+			URL:             node.GetURL().String(),
+			CurrentTopology: node.GetCurrentTopology(),
+			Active:          active,
+		})
+	}
+	return result
+}
+
+// NodeTopologies - the hash of the topology each node known to the client,
+// active or inactive, was last seen using, by node identifier, without
+// making any request.  Nodes using different topologies indicate a
+// rebalance which is in progress or stuck.  Use ListNodesSnapshot to also
+// tell which nodes are active.
+func (sc *SnowthClient) NodeTopologies() map[string]string {
+	nodes := sc.ListNodesSnapshot()
+	result := make(map[string]string, len(nodes))
+	for _, n := range nodes {
+		result[n.ID] = n.CurrentTopology
+	}
+	return result
+}
+
+// WithStartupTimeout - bound the time spent creating the client, contacting
+// the seed nodes and discovering their peers, so that slow nodes do not hang
+// construction.  Once the timeout elapses, the client is returned with the
+// nodes found so far, or an error if no seed node could be activated.  By
+// default, construction is bounded only by the timeout of each request.
+func WithStartupTimeout(timeout time.Duration) ClientOption {
+	return func(sc *SnowthClient) error {
+		sc.startupTimeout = timeout
+		return nil
+	}
+}
+
+// defaultRetryableStatusCodes - the response status codes on which data
+// requests fail over to another node by default
+var defaultRetryableStatusCodes = []int{
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// statusCodeSet - make a set of status codes
+func statusCodeSet(codes []int) map[int]bool {
+	set := make(map[int]bool, len(codes))
+	for _, code := range codes {
+		set[code] = true
+	}
+	return set
+}
+
+// WithRetryableStatusCodes - set the response status codes on which data
+// requests fail over to another active node, replacing the default of 500,
+// 502, 503 and 504.  Reads fail over, but writes only fail over when the
+// retry policy retries writes.  Proxies in front of nodes may respond with
+// other codes when a node is unavailable.  Giving no codes disables
+// failover.
+func WithRetryableStatusCodes(codes ...int) ClientOption {
+	return func(sc *SnowthClient) error {
+		sc.retryableCodes = statusCodeSet(codes)
+		return nil
+	}
+}
+
+// findActiveNode - find an active node by its identifier, returning nil if
+// no active node has the identifier
+func (sc *SnowthClient) findActiveNode(id string) *SnowthNode {
+	sc.activeNodesMu.RLock()
+	defer sc.activeNodesMu.RUnlock()
+	for _, node := range sc.activeNodes {
+		if node.identifier == id {
+			return node
+		}
+	}
+	return nil
+}
+
+// ErrNodeNotFound - the cause of an error returned when no active node has
+// the identifier given, as distinct from an error making a request
+var ErrNodeNotFound = errors.New("node not found")
+
+// NodeByID - find an active node by its identifier, for callers which keep
+// the identifiers of nodes, such as those working out the owners of metrics
+// themselves, to make requests of the node.  An error wrapping
+// ErrNodeNotFound is returned if no active node has the identifier, noting
+// whether the node is known but inactive.
+func (sc *SnowthClient) NodeByID(id string) (*SnowthNode, error) {
+	if node := sc.findActiveNode(id); node != nil {
+		return node, nil
+	}
+	for _, node := range sc.ListInactiveNodes() {
+		if node.identifier == id {
+			return nil, errors.Wrapf(ErrNodeNotFound, "node %s is inactive",
+				id)
+		}
+	}
+	return nil, errors.Wrapf(ErrNodeNotFound, "no node %s", id)
+}
+
 // do - helper to perform the request for the client
 func (sc *SnowthClient) do(node *SnowthNode, method, url string,
 	body io.Reader, respValue interface{},
 	decodeFunc func(interface{}, io.Reader) error) error {
+	return sc.doContext(context.Background(), node, method, url, body,
+		respValue, decodeFunc)
+}
+
+// doContext - helper to perform a request for the client, as do does, which
+// is aborted when the context given is done
+func (sc *SnowthClient) doContext(ctx context.Context, node *SnowthNode,
+	method, url string, body io.Reader, respValue interface{},
+	decodeFunc func(interface{}, io.Reader) error) error {
+	return sc.doWithHeadersContext(ctx, node, method, url, body, nil,
+		respValue, decodeFunc)
+}
+
+// doWithHeaders - helper to perform a request for the client, which sets
+// the additional headers given on the request
+func (sc *SnowthClient) doWithHeaders(node *SnowthNode, method, url string,
+	body io.Reader, header http.Header, respValue interface{},
+	decodeFunc func(interface{}, io.Reader) error) error {
+	return sc.doWithHeadersContext(context.Background(), node, method, url,
+		body, header, respValue, decodeFunc)
+}
+
+// doWithHeadersContext - helper to perform a request for the client, as
+// doWithHeaders does, which is aborted when the context given is done
+func (sc *SnowthClient) doWithHeadersContext(ctx context.Context,
+	node *SnowthNode, method, url string, body io.Reader, header http.Header,
+	respValue interface{}, decodeFunc func(interface{}, io.Reader) error) error {
+
+	resp, err := sc.send(ctx, node, method, url, body, header)
+	if err != nil || resp == nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// a response with no content, such as a read of a window holding no
+	// data, leaves the value as it is
+	if respValue != nil && resp.StatusCode != http.StatusNoContent {
+		if err := decodeFunc(respValue, resp.Body); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return errors.Wrap(err, "failed to decode")
+		}
+	}
 
-	r, err := http.NewRequest(method, sc.getURL(node, url), body)
+	return nil
+}
+
+// send - helper to send a request for the client, returning the response
+// for the caller to read and close.  A nil response is returned without
+// error when the request is not sent, in dry-run mode, and an error is
+// returned for responses other than success or partial content.  Requests
+// of the data apis, which any node can serve, fail over to the other active
+// nodes when a node responds with a retryable status code, and transient
+// failures are retried according to the retry policy of the client.  As
+// with retrying, writes only fail over when the retry policy retries writes,
+// as a write failing with a retryable status may have been applied.  When
+// the context given is done, the request is aborted, and the error of the
+// context is returned.
+func (sc *SnowthClient) send(ctx context.Context, node *SnowthNode,
+	method, url string, body io.Reader,
+	header http.Header) (*http.Response, error) {
+
+	var (
+		resend   = method == "GET" || sc.retry.RetryWrites
+		retry    = resend && sc.retry.MaxRetries > 0
+		failover = resend && len(sc.retryableCodes) > 0 &&
+			isFailoverPath(url) && !failoverDisabled(ctx)
+	)
+	if !failover && !retry {
+		return sc.sendOnce(ctx, node, method, url, body, header)
+	}
+
+	var bodyBytes []byte
+	if body != nil {
+		// the body is needed in full to be sent again
+		b, err := ioutil.ReadAll(body)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read request body")
+		}
+		bodyBytes = b
+	}
+	nodes := []*SnowthNode{node}
+	if failover {
+		for _, n := range sc.ListActiveNodes() {
+			if n != node {
+				nodes = append(nodes, n)
+			}
+		}
+	}
+	var (
+		resp *http.Response
+		err  error
+	)
+	for i, n := range nodes {
+		resp, err = sc.sendRetry(ctx, n, method, url, bodyBytes, header,
+			retry)
+		se, ok := err.(*SnowthError)
+		if !ok || !sc.retryableCodes[se.StatusCode] || i == len(nodes)-1 {
+			break
+		}
+		sc.Logger.Warnf("retryable response from %s, failing over: %s",
+			n.GetURL().Host, se.Status)
+	}
+	return resp, err
+}
+
+// failoverPaths - the path prefixes of the data apis, requests of which any
+// node can serve
+var failoverPaths = []string{
+	"/read/", "/write/", "/raw/", "/rollup/", "/histogram/", "/find/",
+}
+
+// isFailoverPath - whether a request of the reference given may fail over to
+// another node
+func isFailoverPath(ref string) bool {
+	u, err := url.Parse(ref)
 	if err != nil {
-		return errors.Wrap(err, "failed to create request")
+		return false
+	}
+	for _, prefix := range failoverPaths {
+		if strings.HasPrefix(u.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// sendOnce - helper to send a request to a single node
+func (sc *SnowthClient) sendOnce(ctx context.Context, node *SnowthNode,
+	method, url string, body io.Reader,
+	header http.Header) (*http.Response, error) {
+
+	var (
+		bodyBytes []byte
+		dryRun    = sc.dryRun != nil && isDryRunRequest(method, url)
+	)
+	if sc.compression && body != nil {
+		b, err := compressBody(body)
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+	if (sc.signer != nil || dryRun) && body != nil {
+		// the body is needed in full to be signed or reported
+		b, err := ioutil.ReadAll(body)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read request body")
+		}
+		bodyBytes = b
+		body = bytes.NewReader(b)
+	}
+
+	r, err := http.NewRequestWithContext(ctx, method, sc.getURL(node, url),
+		body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	sc.setRequestHeaders(r)
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	if sc.compression {
+		r.Header.Set("Accept-Encoding", "gzip")
+		if body != nil {
+			r.Header.Set("Content-Encoding", "gzip")
+		}
+	}
+	if sc.signer != nil {
+		if err := sc.signer(r, bodyBytes); err != nil {
+			return nil, errors.Wrap(err, "failed to sign request")
+		}
+	}
+
+	if dryRun {
+		sc.Logger.Debugf("Snowth Dry Run Request: %+v", r)
+		sc.dryRun(DryRunRequest{
+			Method: r.Method,
+			URL:    r.URL.String(),
+			Header: r.Header,
+			Body:   bodyBytes,
+		})
+		return nil, nil
+	}
+
+	if sc.limiter != nil {
+		if err := sc.limiter.wait(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, errors.Wrap(err, "failed waiting for rate limit")
+		}
 	}
 
 	sc.Logger.Debugf("Snowth Request: %+v", r)
 
-	var start = time.Now()
+	var (
+		start = time.Now()
+		obs   = RequestObservation{
+			Method: method,
+			Path:   r.URL.Path,
+			NodeID: node.identifier,
+		}
+	)
 	resp, err := sc.c.Do(r)
+	obs.Duration = time.Since(start)
 	if err != nil {
-		return errors.Wrap(err, "failed to perform request")
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		} else {
+			err = errors.Wrap(err, "failed to perform request")
+		}
+		obs.Err = err
+		sc.observer.ObserveRequest(obs)
+		return nil, err
 	}
 
 	sc.Logger.Debugf("Snowth Response: %+v", resp)
-	sc.Logger.Debugf("Snowth Response Latency: %+v", time.Now().Sub(start))
-	defer resp.Body.Close()
+	sc.Logger.Debugf("Snowth Response Latency: %+v", obs.Duration)
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := ioutil.ReadAll(resp.Body)
-		return fmt.Errorf("non-success status code returned: %s -> %s",
-			resp.Status, string(body))
+	obs.StatusCode = resp.StatusCode
+	if sc.compression {
+		decompressResponse(resp)
 	}
-
-	if respValue != nil {
-		if err := decodeFunc(respValue, resp.Body); err != nil {
-			return errors.Wrap(err, "failed to decode")
-		}
+	if err := checkResponse(resp); err != nil {
+		obs.Err = err
+		sc.observer.ObserveRequest(obs)
+		return nil, err
 	}
+	sc.observer.ObserveRequest(obs)
 
-	return nil
+	return resp, nil
 }
 
 // getURL - helper to resolve a reference against a particular node
 func (sc *SnowthClient) getURL(node *SnowthNode, ref string) string {
-	return resolveURL(node.url, ref)
+	return resolveURL(node.GetURL(), ref)
 }
@@ -1,6 +1,45 @@
 package gosnowth
 
-import "testing"
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestServer - create a test snowth node, which will answer state
+// requests so that clients may be bootstrapped against it, and passes all
+// other requests to the handler given.
+func newTestServer(handler http.HandlerFunc) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/state" {
+				w.Write([]byte(stateTestData))
+				return
+			}
+			handler(w, r)
+		}))
+}
+
+// newTestClient - create a client bootstrapped against a test server,
+// returning the client and the node of the test server.
+func newTestClient(t *testing.T, ts *httptest.Server) (*SnowthClient, *SnowthNode) {
+	sc, err := NewSnowthClient(false, ts.URL)
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	return sc, sc.ListActiveNodes()[0]
+}
 
 func TestNewSnowthClient(t *testing.T) {
 
@@ -16,3 +55,612 @@ func TestIsNodeActive(t *testing.T) {
 	// mock out GetNodeState, GetGossipInfo
 
 }
+
+func TestListActiveNodesSnapshot(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			sc.populateNodeInfo("hash", nil, TopologyNode{
+				ID:      node.identifier,
+				Address: "127.0.0.1",
+				APIPort: uint16(8000 + i),
+			})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		for _, info := range sc.ListActiveNodesSnapshot() {
+			assert.Equal(t, node.identifier, info.ID)
+			assert.NotEmpty(t, info.URL)
+			assert.True(t, info.Active)
+		}
+	}
+	wg.Wait()
+	snapshot := sc.ListActiveNodesSnapshot()
+	assert.Equal(t, "http://127.0.0.1:8099", snapshot[0].URL)
+	assert.Equal(t, "hash", snapshot[0].CurrentTopology)
+}
+
+func TestNodeTopologies(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	defer sc.Close()
+	stale := &SnowthNode{
+		identifier:      "stale",
+		url:             &url.URL{Scheme: "http", Host: "127.0.0.1:1"},
+		currentTopology: "old",
+	}
+	sc.AddNodes(stale)
+
+	assert.Equal(t, map[string]string{
+		node.identifier: node.GetCurrentTopology(),
+		"stale":         "old",
+	}, sc.NodeTopologies(), "active and inactive nodes should be listed")
+
+	snapshot := sc.ListNodesSnapshot()
+	if assert.Equal(t, 2, len(snapshot)) {
+		assert.True(t, snapshot[0].Active)
+		assert.Equal(t, "stale", snapshot[1].ID)
+		assert.False(t, snapshot[1].Active)
+	}
+}
+
+func TestRetryableStatusCodes(t *testing.T) {
+	var reads int32
+	failing := newRingNodeTestServer("aaaaaaaa-0000-0000-0000-000000000000", 2,
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})
+	defer failing.Close()
+	healthy := newRingNodeTestServer("bbbbbbbb-0000-0000-0000-000000000000", 2,
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&reads, 1)
+			w.Write([]byte("[[1380000000,1]]"))
+		})
+	defer healthy.Close()
+
+	read := func(opts ...ClientOption) ([]NNTValue, error) {
+		sc, err := NewSnowthClientWithOptions(false,
+			[]string{failing.URL, healthy.URL}, opts...)
+		if err != nil {
+			t.Fatal("failed to create client: ", err)
+		}
+		var node *SnowthNode
+		for _, n := range sc.ListActiveNodes() {
+			if "http://"+n.url.Host == failing.URL {
+				node = n
+			}
+		}
+		if node == nil {
+			t.Fatal("failing node is not active")
+		}
+		return sc.ReadNNTValues(node, time.Unix(1380000000, 0),
+			time.Unix(1380000300, 0), 300, "count", ringTestUUID, "a")
+	}
+
+	_, err := read()
+	assert.Error(t, err, "a status not retryable by default should fail")
+	assert.Equal(t, int32(0), atomic.LoadInt32(&reads))
+
+	data, err := read(WithRetryableStatusCodes(http.StatusTeapot))
+	if err != nil {
+		t.Fatal("error reading with failover: ", err)
+	}
+	assert.Equal(t, []NNTValue{
+		{Time: time.Unix(1380000000, 0), Value: 1, Float: 1},
+	}, data, "the read should fail over to the healthy node")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&reads))
+}
+
+func TestWriteFailover(t *testing.T) {
+	var writes int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&writes, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	first := newRingNodeTestServer("aaaaaaaa-0000-0000-0000-000000000000", 2,
+		handler)
+	defer first.Close()
+	second := newRingNodeTestServer("bbbbbbbb-0000-0000-0000-000000000000", 2,
+		handler)
+	defer second.Close()
+
+	write := func(opts ...ClientOption) error {
+		sc, err := NewSnowthClientWithOptions(false,
+			[]string{first.URL, second.URL}, opts...)
+		if err != nil {
+			t.Fatal("failed to create client: ", err)
+		}
+		defer sc.Close()
+		if len(sc.ListActiveNodes()) != 2 {
+			t.Fatal("both nodes should be active")
+		}
+		atomic.StoreInt32(&writes, 0)
+		return sc.WriteNNT(sc.ListActiveNodes()[0], NNTData{
+			ID: ringTestUUID, Metric: "a", Offset: 1380000000, Count: 1,
+		})
+	}
+
+	assert.Error(t, write(), "a failed write should fail")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&writes),
+		"a failed write should not be replayed on another node")
+
+	assert.Error(t, write(WithRetryPolicy(RetryPolicy{RetryWrites: true})))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&writes),
+		"a failed write should fail over when writes are retried")
+}
+
+func TestContextCancellation(t *testing.T) {
+	release := make(chan struct{})
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		w.Write([]byte("[]"))
+	})
+	defer ts.Close()
+	defer close(release)
+	sc, node := newTestClient(t, ts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	_, err := sc.ReadNNTValuesContext(ctx, node, time.Now(), time.Now(), 60,
+		"count", "id", "metric")
+	assert.Equal(t, context.Canceled, err,
+		"an in-flight request should be aborted")
+
+	_, err = sc.GetNodeStateContext(ctx, node)
+	assert.Equal(t, context.Canceled, err,
+		"a request with a cancelled context should not be sent")
+
+	ctx, cancel = context.WithTimeout(context.Background(),
+		50*time.Millisecond)
+	defer cancel()
+	err = sc.WriteTextContext(ctx, node, TextData{ID: "id", Metric: "metric",
+		Offset: "1380000000"})
+	assert.Equal(t, context.DeadlineExceeded, err,
+		"a request past its deadline should be aborted")
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	start, end := time.Unix(1380000000, 0), time.Unix(1380000060, 0)
+	for name, call := range map[string]func() error{
+		"WriteNNTContext": func() error {
+			return sc.WriteNNTContext(ctx, node, NNTData{ID: "id",
+				Metric: "metric", Offset: 1380000000, Count: 1})
+		},
+		"WriteHistogramContext": func() error {
+			return sc.WriteHistogramContext(ctx, node,
+				HistogramData{ID: "id", Metric: "metric",
+					Offset: 1380000000, Period: 60})
+		},
+		"ReadNNTAllValuesContext": func() error {
+			_, err := sc.ReadNNTAllValuesContext(ctx, node,
+				start, end, 60, "id", "metric")
+			return err
+		},
+		"ReadHistogramValuesContext": func() error {
+			_, err := sc.ReadHistogramValuesContext(ctx, node,
+				start, end, 60, "id", "metric")
+			return err
+		},
+		"ReadRollupValuesContext": func() error {
+			_, err := sc.ReadRollupValuesContext(ctx, node, "id",
+				"metric", nil, time.Minute, start, end)
+			return err
+		},
+		"FindTagsContext": func() error {
+			_, err := sc.FindTagsContext(ctx, node, 1, "test",
+				"1380000000", "1380000060")
+			return err
+		},
+		"LocateMetricContext": func() error {
+			_, err := sc.LocateMetricContext(ctx, "id", "metric", node)
+			return err
+		},
+		"DeleteMetricContext": func() error {
+			return sc.DeleteMetricContext(ctx, node, "id", "metric")
+		},
+		"WriteRawContext": func() error {
+			return sc.WriteRawContext(ctx, node,
+				strings.NewReader(""), true, 0)
+		},
+		"GetGossipContext": func() error {
+			_, err := sc.GetGossipContext(ctx, node)
+			return err
+		},
+		"PingContext": func() error {
+			return sc.PingContext(ctx, node)
+		},
+	} {
+		assert.Equal(t, context.Canceled, call(),
+			"%s should not send with a cancelled context", name)
+	}
+}
+
+func TestClose(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {})
+	sc, _ := newTestClient(t, ts)
+	sc.Close()
+	sc.Close()
+	ts.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, runtime.NumGoroutine() <= before,
+		"no goroutines of the client should remain after close")
+}
+
+func TestPopulateNodeInfoNewNode(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	defer sc.Close()
+
+	inactive := &SnowthNode{
+		identifier: "inactive",
+		url:        &url.URL{Scheme: "http", Host: "127.0.0.1:1"},
+	}
+	sc.AddNodes(inactive)
+	assert.Equal(t, 1, len(sc.ListInactiveNodes()))
+
+	sc.populateNodeInfo("hash", nil, TopologyNode{
+		ID:      "new",
+		Address: "127.0.0.1",
+		APIPort: 8112,
+	})
+	var ids []string
+	for _, n := range sc.ListActiveNodes() {
+		ids = append(ids, n.identifier)
+	}
+	assert.Equal(t, []string{node.identifier, "new"}, ids,
+		"a node absent from both lists should be added and activated")
+	assert.Equal(t, []*SnowthNode{inactive}, sc.ListInactiveNodes())
+}
+
+func TestDiscoverNodesHTTPS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/state" {
+				w.Write([]byte(stateTestData))
+				return
+			}
+			ringTestHandler(w, r)
+		}))
+	defer ts.Close()
+	sc, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithHTTPClient(ts.Client()))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+
+	if err := sc.discoverNodes(); err != nil {
+		t.Fatal("failed to discover nodes: ", err)
+	}
+	nodes := sc.ListActiveNodes()
+	assert.True(t, len(nodes) > 1, "peers should be discovered")
+	for _, n := range nodes {
+		assert.Equal(t, "https", n.GetURL().Scheme,
+			"discovered nodes should keep the scheme of the seed node")
+	}
+}
+
+func TestDiscoverNodesBasePath(t *testing.T) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			paths = append(paths, r.URL.Path)
+			switch {
+			case r.URL.Path == "/irondb/state":
+				w.Write([]byte(stateTestData))
+			case strings.HasPrefix(r.URL.Path, "/irondb/topology/xml/"):
+				w.Write([]byte(ringTopologyXMLTestData))
+			case strings.HasPrefix(r.URL.Path, "/irondb/read/"):
+				w.Write([]byte("[[1380000000,1]]"))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+	defer ts.Close()
+	sc, err := NewSnowthClient(false, ts.URL+"/irondb/")
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+
+	if err := sc.discoverNodes(); err != nil {
+		t.Fatal("failed to discover nodes: ", err)
+	}
+	for _, n := range sc.ListActiveNodes() {
+		assert.Equal(t, "/irondb/", n.GetURL().Path,
+			"discovered nodes should keep the base path of the seed node")
+	}
+
+	_, err = sc.ReadNNTValues(sc.ListActiveNodes()[0],
+		time.Unix(1380000000, 0), time.Unix(1380000060, 0), 60, "count",
+		"id", "metric")
+	assert.NoError(t, err)
+	assert.Equal(t, "/irondb/read/1380000000/1380000060/60/id/count/metric",
+		paths[len(paths)-1], "reads should be made under the base path")
+}
+
+func TestDiscoverNodesDeduplicates(t *testing.T) {
+	ts := newTestServer(ringTestHandler)
+	defer ts.Close()
+	sc, err := NewSnowthClient(false, ts.URL, ts.URL)
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+
+	for i := 0; i < 2; i++ {
+		if err := sc.discoverNodes(); err != nil {
+			t.Fatal("failed to discover nodes: ", err)
+		}
+	}
+	sc.AddNodes(&SnowthNode{
+		identifier: "aaaaaaaa-0000-0000-0000-000000000000",
+		url:        &url.URL{Scheme: "http", Host: "127.0.0.1:1"},
+	})
+
+	seen := make(map[string]int)
+	for _, n := range append(sc.ListActiveNodes(), sc.ListInactiveNodes()...) {
+		seen[n.identifier]++
+	}
+	assert.Equal(t, 4, len(seen), "the seed and its three peers should be known")
+	for id, count := range seen {
+		assert.Equal(t, 1, count, "node %s should be known once", id)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		nodes string
+	)
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/topology/xml/") {
+			mu.Lock()
+			defer mu.Unlock()
+			w.Write([]byte(`<nodes n="2">` + nodes + `</nodes>`))
+		}
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	defer sc.Close()
+	host, port, _ := net.SplitHostPort(node.GetURL().Host)
+	nodes = `<node id="bb6f7162-4828-11df-bab8-6bac200dcc2a" address="` +
+		host + `" port="8112" apiport="` + port + `" weight="32"/>`
+
+	if err := sc.Discover(); err != nil {
+		t.Fatal("failed to discover nodes: ", err)
+	}
+	assert.Equal(t, 1, len(sc.ListActiveNodes()))
+
+	// the cluster is resized
+	mu.Lock()
+	nodes += `<node id="8c2fc7b8-c569-402d-a393-db433fb267aa" ` +
+		`address="10.0.0.2" port="8112" apiport="8112" weight="32"/>`
+	mu.Unlock()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, sc.Discover())
+		}()
+	}
+	wg.Wait()
+	var ids []string
+	for _, n := range sc.ListActiveNodes() {
+		ids = append(ids, n.identifier)
+	}
+	assert.Equal(t, []string{
+		"bb6f7162-4828-11df-bab8-6bac200dcc2a",
+		"8c2fc7b8-c569-402d-a393-db433fb267aa",
+	}, ids, "the added node should be discovered once")
+	topology, err := sc.CurrentTopology()
+	if err != nil {
+		t.Fatal("error getting current topology: ", err)
+	}
+	assert.Equal(t, 2, len(topology.Nodes))
+}
+
+func TestWithoutDiscovery(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/topology/xml/"):
+			// the addresses of the nodes behind the proxy are unreachable
+			w.Write([]byte(`<nodes n="2">` +
+				`<node id="bb6f7162-4828-11df-bab8-6bac200dcc2a" ` +
+				`address="10.0.0.1" port="8112" apiport="8112" weight="32"/>` +
+				`<node id="8c2fc7b8-c569-402d-a393-db433fb267aa" ` +
+				`address="10.0.0.2" port="8112" apiport="8112" weight="32"/>` +
+				`</nodes>`))
+		case strings.HasPrefix(r.URL.Path, "/read/"):
+			w.Write([]byte("[[1380000000,1]]"))
+		}
+	})
+	defer ts.Close()
+	sc, err := NewSnowthClientWithOptions(true, []string{ts.URL},
+		WithoutDiscovery())
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+
+	assert.NoError(t, sc.Discover())
+	nodes := append(sc.ListActiveNodes(), sc.ListInactiveNodes()...)
+	if assert.Equal(t, 1, len(nodes), "only the seed should be used") {
+		assert.Equal(t, ts.URL, nodes[0].GetURL().String(),
+			"the address of the seed should be kept")
+	}
+	topology, err := sc.CurrentTopology()
+	if assert.NoError(t, err, "the topology should still be cached") {
+		assert.Equal(t, 2, len(topology.Nodes))
+	}
+
+	values, err := sc.ReadNNTValues(nodes[0], time.Unix(1380000000, 0),
+		time.Unix(1380000060, 0), 60, "count", "id", "metric")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(values), "reads should be made of the seed")
+	assert.NoError(t, sc.WriteNNT(nodes[0], NNTData{ID: "id",
+		Metric: "metric", Offset: 1380000000, Count: 1}))
+
+	// the seed serves every metric, whichever nodes own it on the ring
+	var writes int32
+	proxy := newRingNodeTestServer("aaaaaaaa-0000-0000-0000-000000000000", 2,
+		func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case strings.HasPrefix(r.URL.Path, "/read/"):
+				w.Write([]byte("[[1380000000,\"text\"]]"))
+			case r.URL.Path == "/write/text":
+				atomic.AddInt32(&writes, 1)
+			}
+		})
+	defer proxy.Close()
+	sc, err = NewSnowthClientWithOptions(true, []string{proxy.URL},
+		WithoutDiscovery())
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+	owners, _, err := sc.NodesForMetric(ringTestUUID, "a")
+	if assert.NoError(t, err, "the seed should own every metric") {
+		assert.Equal(t, sc.ListActiveNodes(), owners)
+	}
+	text, node, err := sc.ReadTextValuesAny(time.Unix(1380000000, 0),
+		time.Unix(1380000060, 0), ringTestUUID, "a")
+	if assert.NoError(t, err, "routed reads should be made of the seed") {
+		assert.Equal(t, 1, len(text))
+		assert.Equal(t, sc.ListActiveNodes()[0], node)
+	}
+	assert.NoError(t, sc.WriteTextRouted(NewTextData(ringTestUUID, "a",
+		time.Unix(1380000000, 0), "text")))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&writes),
+		"routed writes should be made to the seed")
+}
+
+func TestNodeByID(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	defer sc.Close()
+
+	found, err := sc.NodeByID("bb6f7162-4828-11df-bab8-6bac200dcc2a")
+	assert.NoError(t, err)
+	assert.Equal(t, node, found)
+
+	_, err = sc.NodeByID("unknown")
+	assert.Equal(t, ErrNodeNotFound, errors.Cause(err))
+
+	sc.DeactivateNodes(node)
+	_, err = sc.NodeByID("bb6f7162-4828-11df-bab8-6bac200dcc2a")
+	assert.Equal(t, ErrNodeNotFound, errors.Cause(err))
+	assert.Contains(t, err.Error(), "inactive")
+}
+
+func TestWithStartupTimeout(t *testing.T) {
+	release := make(chan struct{})
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		// the node is slow to report its topology
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	defer ts.Close()
+	defer close(release)
+
+	start := time.Now()
+	sc, err := NewSnowthClientWithOptions(true, []string{ts.URL},
+		WithStartupTimeout(100*time.Millisecond),
+		WithRetryPolicy(RetryPolicy{}))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+	assert.True(t, time.Since(start) < 2*time.Second,
+		"construction should return once the timeout elapses")
+	assert.Equal(t, 1, len(sc.ListActiveNodes()),
+		"the seed node should be kept without its peers")
+}
+
+func TestNodeListsConcurrentUse(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {})
+	defer ts.Close()
+	sc, seed := newTestClient(t, ts)
+	defer sc.Close()
+
+	var nodes []*SnowthNode
+	for i := 0; i < 4; i++ {
+		nodes = append(nodes, &SnowthNode{
+			identifier: string(rune('a' + i)),
+			url: &url.URL{Scheme: "http",
+				Host: "127.0.0.1:" + string(rune('1'+i))},
+		})
+	}
+	sc.AddNodes(nodes...)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				sc.ActivateNodes(nodes...)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				sc.DeactivateNodes(nodes...)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				for _, n := range sc.ListActiveNodes() {
+					_ = n.GetURL().String()
+					_ = n.GetCurrentTopology()
+				}
+				sc.ListInactiveNodes()
+				sc.ListActiveNodesSnapshot()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				sc.populateNodeInfo("hash", nil, TopologyNode{
+					ID:      "a",
+					Address: "127.0.0.1",
+					APIPort: 1,
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[string]int)
+	for _, n := range append(sc.ListActiveNodes(), sc.ListInactiveNodes()...) {
+		seen[n.identifier]++
+	}
+	assert.Equal(t, map[string]int{
+		seed.identifier: 1, "a": 1, "b": 1, "c": 1, "d": 1,
+	}, seen, "each node should be in exactly one list, once")
+}
@@ -0,0 +1,112 @@
+package gosnowth
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnknownCluster - returned when routing to a cluster which has not been
+// registered
+var ErrUnknownCluster = errors.New("unknown cluster")
+
+// SnowthClusters - a set of separate snowth clusters, such as one per
+// region, each registered under a name with its own seed nodes and
+// topology.  Reads and writes are routed to a cluster by its name.  The
+// clients of the clusters are closed when they are unregistered, or when the
+// set is closed.
+type SnowthClusters struct {
+	mu       sync.RWMutex
+	clusters map[string]*SnowthClient
+}
+
+// NewSnowthClusters - create an empty set of clusters
+func NewSnowthClusters() *SnowthClusters {
+	return &SnowthClusters{
+		clusters: make(map[string]*SnowthClient),
+	}
+}
+
+// Register - create a client for a cluster from its seed node addresses,
+// as NewSnowthClientWithOptions does, and register it under the name given.
+// The client is created without holding the lock of the set, so that other
+// clusters may be used while its seed nodes are contacted.
+func (scs *SnowthClusters) Register(name string, discover bool,
+	addrs []string, opts ...ClientOption) error {
+
+	if scs.registered(name) {
+		return errors.Errorf("cluster already registered: %s", name)
+	}
+	sc, err := NewSnowthClientWithOptions(discover, addrs, opts...)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create client for cluster %s",
+			name)
+	}
+	scs.mu.Lock()
+	if _, ok := scs.clusters[name]; ok {
+		scs.mu.Unlock()
+		sc.Close()
+		return errors.Errorf("cluster already registered: %s", name)
+	}
+	scs.clusters[name] = sc
+	scs.mu.Unlock()
+	return nil
+}
+
+// registered - check whether a cluster is registered under the name given
+func (scs *SnowthClusters) registered(name string) bool {
+	scs.mu.RLock()
+	defer scs.mu.RUnlock()
+	_, ok := scs.clusters[name]
+	return ok
+}
+
+// Cluster - get the client for the cluster registered under the name given,
+// through which reads and writes are routed to that cluster
+func (scs *SnowthClusters) Cluster(name string) (*SnowthClient, error) {
+	scs.mu.RLock()
+	defer scs.mu.RUnlock()
+	sc, ok := scs.clusters[name]
+	if !ok {
+		return nil, errors.Wrap(ErrUnknownCluster, name)
+	}
+	return sc, nil
+}
+
+// Unregister - remove the cluster registered under the name given, closing
+// its client
+func (scs *SnowthClusters) Unregister(name string) error {
+	scs.mu.Lock()
+	sc, ok := scs.clusters[name]
+	delete(scs.clusters, name)
+	scs.mu.Unlock()
+	if !ok {
+		return errors.Wrap(ErrUnknownCluster, name)
+	}
+	sc.Close()
+	return nil
+}
+
+// Close - unregister every cluster, closing their clients
+func (scs *SnowthClusters) Close() {
+	scs.mu.Lock()
+	clusters := scs.clusters
+	scs.clusters = make(map[string]*SnowthClient)
+	scs.mu.Unlock()
+	for _, sc := range clusters {
+		sc.Close()
+	}
+}
+
+// Names - list the names of the registered clusters, in sorted order
+func (scs *SnowthClusters) Names() []string {
+	scs.mu.RLock()
+	defer scs.mu.RUnlock()
+	names := make([]string, 0, len(scs.clusters))
+	for name := range scs.clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
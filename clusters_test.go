@@ -0,0 +1,127 @@
+package gosnowth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnowthClusters(t *testing.T) {
+	writes := make(map[string]int)
+	newServer := func(name string) func(http.ResponseWriter, *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			writes[name]++
+		}
+	}
+	east := newTestServer(newServer("east"))
+	defer east.Close()
+	west := newTestServer(newServer("west"))
+	defer west.Close()
+
+	scs := NewSnowthClusters()
+	defer scs.Close()
+	if err := scs.Register("east", false, []string{east.URL}); err != nil {
+		t.Fatal("failed to register cluster: ", err)
+	}
+	if err := scs.Register("west", false, []string{west.URL}); err != nil {
+		t.Fatal("failed to register cluster: ", err)
+	}
+	assert.Error(t, scs.Register("east", false, []string{east.URL}),
+		"clusters should not be registered twice")
+	assert.Equal(t, []string{"east", "west"}, scs.Names())
+
+	for _, name := range []string{"east", "west", "west"} {
+		sc, err := scs.Cluster(name)
+		if err != nil {
+			t.Fatal("failed to route to cluster: ", err)
+		}
+		err = sc.WriteNNT(sc.ListActiveNodes()[0], NNTData{
+			ID: "id", Metric: "metric", Count: 1,
+		})
+		if err != nil {
+			t.Fatal("error writing nnt data: ", err)
+		}
+	}
+	assert.Equal(t, map[string]int{"east": 1, "west": 2}, writes,
+		"writes should be routed to the named cluster")
+
+	_, err := scs.Cluster("north")
+	assert.Equal(t, ErrUnknownCluster, errors.Cause(err))
+
+	sc, err := scs.Cluster("east")
+	if err != nil {
+		t.Fatal("failed to route to cluster: ", err)
+	}
+	assert.NoError(t, scs.Unregister("east"))
+	assert.Equal(t, []string{"west"}, scs.Names())
+	select {
+	case <-sc.stopped:
+	default:
+		t.Error("the client of an unregistered cluster should be closed")
+	}
+	assert.Equal(t, ErrUnknownCluster, errors.Cause(scs.Unregister("east")))
+
+	sc, err = scs.Cluster("west")
+	if err != nil {
+		t.Fatal("failed to route to cluster: ", err)
+	}
+	scs.Close()
+	assert.Empty(t, scs.Names())
+	select {
+	case <-sc.stopped:
+	default:
+		t.Error("the clients of a closed set should be closed")
+	}
+}
+
+func TestSnowthClustersRegisterUnlocked(t *testing.T) {
+	requested := make(chan struct{}, 1)
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case requested <- struct{}{}:
+			default:
+			}
+			<-release
+			w.Write([]byte(stateTestData))
+		}))
+	defer slow.Close()
+	var releaseOnce sync.Once
+	defer releaseOnce.Do(func() { close(release) })
+
+	scs := NewSnowthClusters()
+	defer scs.Close()
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			errs <- scs.Register("slow", false, []string{slow.URL})
+		}()
+	}
+	<-requested
+
+	names := make(chan []string)
+	go func() { names <- scs.Names() }()
+	select {
+	case n := <-names:
+		assert.Empty(t, n)
+	case <-time.After(time.Second):
+		t.Fatal("the set should not be locked while creating a client")
+	}
+
+	releaseOnce.Do(func() { close(release) })
+	var failed int
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			failed++
+		}
+	}
+	assert.Equal(t, 1, failed,
+		"only one registration under a name should succeed")
+	assert.Equal(t, []string{"slow"}, scs.Names())
+}
@@ -2,7 +2,6 @@ package example
 
 import (
 	"log"
-	"strconv"
 	"time"
 
 	"github.com/circonus-labs/gosnowth"
@@ -23,12 +22,11 @@ func ExampleReadNNT() {
 	if err != nil {
 		log.Fatalf("failed to create snowth client: %v", err)
 	}
-	// write text data in order to read back the data
+	// write nnt data in order to read back the data
 	guid, _ := uuid.NewV4()
 	for _, node := range client.ListActiveNodes() {
-		// create a new metric ID, a UUIDv4
-		// WriteText takes in a node and variadic of
-		// gosnowth.TextData entries
+		// WriteNNT takes in a node and variadic of
+		// gosnowth.NNTData entries
 		err := client.WriteNNT(node,
 			gosnowth.NNTData{
 				Metric: "test-metric", ID: guid.String(),
@@ -46,7 +44,7 @@ func ExampleReadNNT() {
 			})
 
 		if err != nil {
-			log.Fatalf("failed to write text data: %v", err)
+			log.Fatalf("failed to write nnt data: %v", err)
 		}
 
 		data, err := client.ReadNNTValues(node,
@@ -81,14 +79,13 @@ func ExampleReadText() {
 	// write text data in order to read back the data
 	for _, node := range client.ListActiveNodes() {
 		guid, _ := uuid.NewV4()
-
+		// WriteText takes in a node and variadic of
+		// gosnowth.TextData entries
 		err := client.WriteText(
 			node,
-			gosnowth.TextData{
-				Metric: "test-text-metric2", ID: guid.String(),
-				Offset: strconv.FormatInt(time.Now().Unix(), 10),
-				Value:  "a_text_data_value",
-			})
+			// NewTextData formats the offset of the time given
+			gosnowth.NewTextData(guid.String(), "test-text-metric2",
+				time.Now(), "a_text_data_value"))
 		if err != nil {
 			log.Fatalf("failed to write text data: %v", err)
 		}
@@ -2,7 +2,6 @@ package example
 
 import (
 	"log"
-	"strconv"
 	"time"
 
 	"github.com/circonus-labs/circonusllhist"
@@ -32,17 +31,45 @@ func ExampleSubmitText() {
 		// gosnowth.TextData entries
 		err := client.WriteText(
 			node,
-			gosnowth.TextData{
-				Metric: "test-text-metric2", ID: guid.String(),
-				Offset: strconv.FormatInt(time.Now().Unix(), 10),
-				Value:  "a_text_data_value",
-			})
+			// NewTextData formats the offset of the time given
+			gosnowth.NewTextData(guid.String(), "test-text-metric2",
+				time.Now(), "a_text_data_value"))
 		if err != nil {
 			log.Fatalf("failed to write text data: %v", err)
 		}
 	}
 }
 
+// ExampleSubmitNumeric - this example shows how you
+// can submit a plain numeric metric to a particular snowth
+// node.  In this example you need snowth nodes running
+// at http://localhost:8112 and http://localhost:8113
+func ExampleSubmitNumeric() {
+	// create a client, with a seed of nodes
+	client, err := gosnowth.NewSnowthClient(
+		true,
+		"http://localhost:8112",
+		"http://localhost:8113",
+	)
+	if err != nil {
+		log.Fatalf("failed to create snowth client: %v", err)
+	}
+	// write numeric data
+	for _, node := range client.ListActiveNodes() {
+		// create a new metric ID, a UUIDv4
+		guid, _ := uuid.NewV4()
+		// WriteNumeric takes in a node and variadic of
+		// gosnowth.NumericData entries
+		err := client.WriteNumeric(
+			node,
+			gosnowth.NewNumericData(guid.String(), "test-numeric-metric",
+				time.Now(), 42.5))
+		if err != nil {
+			log.Fatalf("failed to write numeric data: %v", err)
+		}
+	}
+}
+
 // ExampleSubmitNNT - this example shows how you
 // can submit an NNT metric to a particular snowth
 // node.  In this example you need snowth nodes running
@@ -5,17 +5,39 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"io"
+	"io/ioutil"
 	"net/url"
 	"strings"
 
 	"github.com/pkg/errors"
 )
 
-// resolveURL - given a URL and a string reference, it will resolve the
-// address of the URL plus the reference
+// resolveURL - given a URL and a string reference, a path with an optional
+// query, it will resolve the address of the URL plus the reference.  The
+// reference path, whether it begins with a slash or not, is joined to the
+// path of the URL, so that nodes served under a base path, such as behind a
+// reverse proxy, are addressed beneath it.  The path and query are kept as
+// escaped in the reference, and characters which are invalid in a path,
+// such as a malformed escape, are escaped.
 func resolveURL(baseURL *url.URL, ref string) string {
-	refURL, _ := url.Parse(ref)
-	return baseURL.ResolveReference(refURL).String()
+	var (
+		u     = *baseURL
+		query string
+	)
+	if i := strings.Index(ref, "?"); i >= 0 {
+		ref, query = ref[:i], ref[i+1:]
+	}
+	ref = strings.TrimPrefix(ref, "/")
+	u.RawQuery = query
+	u.Fragment = ""
+	if p, err := url.PathUnescape(ref); err == nil {
+		u.Path = strings.TrimSuffix(baseURL.Path, "/") + "/" + p
+		u.RawPath = strings.TrimSuffix(baseURL.EscapedPath(), "/") + "/" + ref
+	} else {
+		u.Path = strings.TrimSuffix(baseURL.Path, "/") + "/" + ref
+		u.RawPath = ""
+	}
+	return u.String()
 }
 
 // multiError - sometimes you need to keep track of multiple errors,
@@ -59,29 +81,42 @@ func (me *multiError) Error() string {
 // moveNode - move a url from a slice to a new slice, if this is used for
 // SnowthInstances' active or inactive slices wrap in a write lock
 func moveNode(from, dest *[]*SnowthNode, u *SnowthNode) {
-	// put this url in active
-	*dest = append(*dest, u)
-
 	// find the item index in the deactive list
 	var index = -1
 	for i, v := range *from {
-		if v.url.String() == u.url.String() {
+		if v.GetURL().String() == u.GetURL().String() {
 			index = i
 		}
 	}
-	if index != -1 {
-		// remove from deactive
-		*from = removeNode(*from, index)
+	if index == -1 {
+		// the node was already moved, or is unknown, and is not put in
+		// active a second time
+		return
+	}
+	// put this url in active, and remove from deactive
+	*dest = append(*dest, (*from)[index])
+	*from = removeNode(*from, index)
+}
+
+// hasNode - is the node, or a node with the same identifier, in the list
+func hasNode(nodes []*SnowthNode, node *SnowthNode) bool {
+	for _, v := range nodes {
+		if v == node || (node.identifier != "" &&
+			v.identifier == node.identifier) {
+			return true
+		}
 	}
+	return false
 }
 
 // removeNode - remove a url from a slice, if this is used for
-// SnowthInstances' active or inactive slices wrap in a write lock
+// SnowthInstances' active or inactive slices wrap in a write lock.  A new
+// slice is returned, so that the slice given is not modified, and never
+// shares its backing array with the other list.
 func removeNode(a []*SnowthNode, index int) []*SnowthNode {
-	copy(a[index:], a[index+1:])
-	a[len(a)-1] = nil // or the zero value of T
-	a = a[:len(a)-1]
-	return a
+	result := make([]*SnowthNode, 0, len(a)-1)
+	result = append(result, a[:index]...)
+	return append(result, a[index+1:]...)
 }
 
 // decodeJSONFromResponse - given a response decode the body as json
@@ -115,3 +150,13 @@ func decodeXMLFromResponse(v interface{}, reader io.Reader) error {
 	}
 	return nil
 }
+
+// decodeRawFromResponse - read the response body into a byte slice
+func decodeRawFromResponse(v interface{}, reader io.Reader) error {
+	b, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return errors.Wrap(err, "failed to read response body")
+	}
+	*(v.(*[]byte)) = b
+	return nil
+}
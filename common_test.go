@@ -30,6 +30,54 @@ func TestResolveURL(t *testing.T) {
 		"http://localhost:1234/a/resource/path", result, "should equal")
 }
 
+func TestResolveURLBasePath(t *testing.T) {
+	base, _ := url.Parse("http://localhost:1234/irondb/")
+	assert.Equal(t, "http://localhost:1234/irondb/read/1/2/id/a%7Cb",
+		resolveURL(base, "/read/1/2/id/a%7Cb"),
+		"absolute references should be joined to the base path")
+	assert.Equal(t, "http://localhost:1234/irondb/state?x=1",
+		resolveURL(base, "/state?x=1"), "queries should be kept")
+}
+
+func TestResolveURLCases(t *testing.T) {
+	tests := []struct {
+		name string
+		base string
+		ref  string
+		want string
+	}{
+		{"root absolute", "http://h:1", "/state", "http://h:1/state"},
+		{"root relative", "http://h:1", "state", "http://h:1/state"},
+		{"root slash", "http://h:1/", "/state", "http://h:1/state"},
+		{"base absolute", "http://h:1/irondb", "/state",
+			"http://h:1/irondb/state"},
+		{"base relative", "http://h:1/irondb", "state",
+			"http://h:1/irondb/state"},
+		{"base slash relative", "http://h:1/irondb/", "state",
+			"http://h:1/irondb/state"},
+		{"root query", "http://h:1", "/find/1/tags?query=and%28a%3Ab%29",
+			"http://h:1/find/1/tags?query=and%28a%3Ab%29"},
+		{"base query", "http://h:1/irondb/", "/find/1/tags?query=a+b&x=%2F",
+			"http://h:1/irondb/find/1/tags?query=a+b&x=%2F"},
+		{"base relative query", "http://h:1/irondb", "extension/caql?q=1",
+			"http://h:1/irondb/extension/caql?q=1"},
+		{"escaped base", "http://h:1/iron%20db/", "/read/a%2Fb",
+			"http://h:1/iron%20db/read/a%2Fb"},
+		{"base query replaced", "http://h:1/irondb?x=1", "/state",
+			"http://h:1/irondb/state"},
+		{"network path", "http://h:1", "//other/state",
+			"http://h:1//other/state"},
+		{"malformed escape", "http://h:1", "/read/100%", "http://h:1/read/100%25"},
+	}
+	for _, tt := range tests {
+		base, err := url.Parse(tt.base)
+		if err != nil {
+			t.Fatal("failed to parse base url: ", err)
+		}
+		assert.Equal(t, tt.want, resolveURL(base, tt.ref), tt.name)
+	}
+}
+
 func TestMultiError(t *testing.T) {
 	merr := newMultiError()
 	assert.True(t, !merr.HasError(), "should have no errors yet")
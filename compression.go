@@ -0,0 +1,78 @@
+package gosnowth
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// WithCompression - compress the bodies of requests, such as writes, with
+// gzip, and ask nodes to compress their responses, which are decompressed
+// as they are read.  Responses from nodes which ignore the request for
+// compression are read as they are.  This applies to any http client, while
+// the transport of the default http client only negotiates compression of
+// responses.  Request bodies which are signed, or reported in dry-run mode,
+// are signed and reported compressed, as sent.
+func WithCompression() ClientOption {
+	return func(sc *SnowthClient) error {
+		sc.compression = true
+		return nil
+	}
+}
+
+// compressBody - compress a request body with gzip, returning a reader of
+// the compressed body, so that its length is known when it is sent
+func compressBody(body io.Reader) (io.Reader, error) {
+	buf := new(bytes.Buffer)
+	zw := gzip.NewWriter(buf)
+	if _, err := io.Copy(zw, body); err != nil {
+		return nil, errors.Wrap(err, "failed to compress request body")
+	}
+	if err := zw.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to compress request body")
+	}
+	return bytes.NewReader(buf.Bytes()), nil
+}
+
+// decompressResponse - replace the body of a response compressed with gzip
+// with one which decompresses it as it is read.  Responses which are not
+// compressed are left as they are.
+func decompressResponse(resp *http.Response) {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return
+	}
+	resp.Body = &gzipBody{body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+// gzipBody - a response body which is decompressed as it is read.  The gzip
+// reader is created on the first read, so that empty bodies, such as those
+// of HEAD requests, read as empty rather than failing.
+type gzipBody struct {
+	body io.ReadCloser
+	zr   *gzip.Reader
+	err  error
+}
+
+// Read - read decompressed data from the body
+func (gb *gzipBody) Read(p []byte) (int, error) {
+	if gb.zr == nil && gb.err == nil {
+		gb.zr, gb.err = gzip.NewReader(gb.body)
+	}
+	if gb.err != nil {
+		return 0, gb.err
+	}
+	return gb.zr.Read(p)
+}
+
+// Close - close the underlying body
+func (gb *gzipBody) Close() error {
+	return gb.body.Close()
+}
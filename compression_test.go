@@ -0,0 +1,84 @@
+package gosnowth
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithCompression(t *testing.T) {
+	var (
+		written  []map[string]interface{}
+		compress = true
+	)
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/write/nnt":
+			assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Error("request body should be compressed: ", err)
+				return
+			}
+			assert.NoError(t, json.NewDecoder(zr).Decode(&written))
+			return
+		case "/read/1380000000/1380000060/60/id/count/missing":
+			w.Header().Set("Content-Encoding", "gzip")
+			w.WriteHeader(http.StatusNotFound)
+			zw := gzip.NewWriter(w)
+			zw.Write([]byte(`{"error":"not found","message":"no data"}`))
+			zw.Close()
+			return
+		}
+		body := "[[1380000000,1]]"
+		if !compress ||
+			!strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Write([]byte(body))
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		zw.Write([]byte(body))
+		zw.Close()
+	})
+	defer ts.Close()
+	sc, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithCompression())
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+	node := sc.ListActiveNodes()[0]
+
+	assert.NoError(t, sc.WriteNNT(node, NNTData{ID: "id", Metric: "metric",
+		Offset: 1380000000, Count: 1, Value: 1}))
+	if assert.Equal(t, 1, len(written), "the write should round trip") {
+		assert.Equal(t, "metric", written[0]["metric"])
+		assert.Equal(t, float64(1380000000), written[0]["offset"])
+	}
+
+	start, end := time.Unix(1380000000, 0), time.Unix(1380000060, 0)
+	for _, compress = range []bool{true, false} {
+		values, err := sc.ReadNNTValues(node, start, end, 60, "count", "id",
+			"metric")
+		if err != nil {
+			t.Fatal("error reading nnt values: ", err)
+		}
+		assert.Equal(t, []NNTValue{{Time: start, Value: 1, Float: 1}},
+			values,
+			"responses should be read whether compressed or not")
+	}
+
+	_, err = sc.ReadNNTValues(node, start, end, 60, "count", "id", "missing")
+	se, ok := err.(*SnowthError)
+	if !ok {
+		t.Fatal("expected a snowth error, got: ", err)
+	}
+	assert.Equal(t, "no data", se.Message,
+		"compressed error responses should be decompressed")
+}
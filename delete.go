@@ -0,0 +1,26 @@
+package gosnowth
+
+import (
+	"context"
+	"net/url"
+	"path"
+)
+
+// DeleteMetric - delete all of the data and metadata of a metric from a
+// node.  The deletion is not replicated by the node, so it should be made
+// on every node owning the metric.  An error wrapping ErrMetricNotFound is
+// returned if the node holds no such metric, as for reads, and a SnowthError
+// if the node fails.
+func (sc *SnowthClient) DeleteMetric(node *SnowthNode, uuid,
+	metric string) error {
+	return sc.DeleteMetricContext(context.Background(), node, uuid, metric)
+}
+
+// DeleteMetricContext - delete the data and metadata of a metric from a node,
+// as DeleteMetric does, aborting the request when the context given is done
+func (sc *SnowthClient) DeleteMetricContext(ctx context.Context,
+	node *SnowthNode, uuid, metric string) error {
+
+	return sc.doContext(ctx, node, "DELETE", path.Join("/full/canonical",
+		uuid, url.PathEscape(sc.metricName(metric))), nil, nil, nil)
+}
@@ -0,0 +1,33 @@
+package gosnowth
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteMetric(t *testing.T) {
+	var status int
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DELETE", r.Method)
+		assert.Equal(t, "/full/canonical/id/cpu|ST[a:b]", r.URL.Path)
+		w.WriteHeader(status)
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	status = http.StatusOK
+	assert.NoError(t, sc.DeleteMetric(node, "id", "cpu|ST[a:b]"))
+
+	status = http.StatusNotFound
+	err := sc.DeleteMetric(node, "id", "cpu|ST[a:b]")
+	assert.True(t, errors.Is(err, ErrMetricNotFound),
+		"a missing metric should be reported as not found: %v", err)
+
+	status = http.StatusInternalServerError
+	err = sc.DeleteMetric(node, "id", "cpu|ST[a:b]")
+	_, ok := err.(*SnowthError)
+	assert.True(t, ok, "a server error should be a SnowthError: %v", err)
+}
@@ -0,0 +1,148 @@
+package gosnowth
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// MetricDistribution - report how the metrics given are distributed across
+// the nodes of the cluster, according to the topology ring in use by the node
+// given.  The result maps node identifiers to the number of the metrics each
+// node stores, with each metric counted against every node keeping a copy.
+func (sc *SnowthClient) MetricDistribution(node *SnowthNode,
+	metrics ...MetricRef) (map[string]int, error) {
+	return sc.MetricDistributionContext(context.Background(), node,
+		metrics...)
+}
+
+// MetricDistributionContext - report the distribution of metrics across nodes,
+// as MetricDistribution does, aborting the requests when the context given is
+// done
+func (sc *SnowthClient) MetricDistributionContext(ctx context.Context,
+	node *SnowthNode, metrics ...MetricRef) (map[string]int, error) {
+
+	ring, err := sc.fetchMetricRing(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+	return ring.distribution(metrics, sc.metricName)
+}
+
+// distribution - count the metrics stored by each node of the ring, locating
+// each by its name as stored, given by the name function
+func (mr *metricRing) distribution(metrics []MetricRef,
+	name func(string) string) (map[string]int, error) {
+
+	result := make(map[string]int)
+	for _, m := range metrics {
+		owners, err := mr.owners(m.ID, name(m.Metric))
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range owners {
+			result[id]++
+		}
+	}
+	return result, nil
+}
+
+// TagQueryDistribution - report how the metrics matching a tag query, which
+// were active within the window given, are distributed across the nodes of
+// the cluster, as MetricDistribution does.
+func (sc *SnowthClient) TagQueryDistribution(node *SnowthNode,
+	accountID int32, query string, start, end time.Time) (map[string]int, error) {
+	return sc.TagQueryDistributionContext(context.Background(), node,
+		accountID, query, start, end)
+}
+
+// TagQueryDistributionContext - report the distribution of the metrics matching
+// a tag query, as TagQueryDistribution does, aborting the requests when the
+// context given is done
+func (sc *SnowthClient) TagQueryDistributionContext(ctx context.Context,
+	node *SnowthNode, accountID int32, query string,
+	start, end time.Time) (map[string]int, error) {
+
+	metrics, err := sc.findMetricRefs(ctx, node, accountID, query, start,
+		end)
+	if err != nil {
+		return nil, err
+	}
+	return sc.MetricDistributionContext(ctx, node, metrics...)
+}
+
+// findMetricRefs - find the metrics matching a tag query, which were active
+// within the window given
+func (sc *SnowthClient) findMetricRefs(ctx context.Context,
+	node *SnowthNode, accountID int32, query string,
+	start, end time.Time) ([]MetricRef, error) {
+
+	items, err := sc.FindTagsContext(ctx, node, accountID, query,
+		strconv.FormatInt(start.Unix(), 10), strconv.FormatInt(end.Unix(), 10))
+	if err != nil {
+		return nil, err
+	}
+	metrics := make([]MetricRef, 0, len(items))
+	for _, item := range items {
+		metrics = append(metrics, MetricRef{
+			ID:     item.UUID,
+			Metric: item.MetricName,
+		})
+	}
+	return metrics, nil
+}
+
+// MetricCardinality - an estimate of the number of metrics stored by a
+// cluster, in total and by each node
+type MetricCardinality struct {
+	// Total is the number of distinct metrics, with each counted once
+	// regardless of how many copies of it are kept.
+	Total int
+	// PerNode is the number of metrics each node stores, by node
+	// identifier, including copies.
+	PerNode map[string]int
+}
+
+// GetMetricCardinality - estimate the metric cardinality of the cluster, for
+// the metrics matching a tag query, such as "and(__name:*)" for all metrics
+// of an account, which were active within the window given.  Snowth does not
+// report the number of metrics each node stores, so the per-node figures
+// are derived from the topology ring in use by the node given.  The total is
+// the sum of the per-node figures divided by the number of copies the
+// topology keeps, de-duplicating the replicated metrics.
+func (sc *SnowthClient) GetMetricCardinality(node *SnowthNode,
+	accountID int32, query string, start, end time.Time) (*MetricCardinality, error) {
+	return sc.GetMetricCardinalityContext(context.Background(), node,
+		accountID, query, start, end)
+}
+
+// GetMetricCardinalityContext - estimate the metric cardinality of the cluster,
+// as GetMetricCardinality does, aborting the requests when the context given is
+// done
+func (sc *SnowthClient) GetMetricCardinalityContext(ctx context.Context,
+	node *SnowthNode, accountID int32, query string,
+	start, end time.Time) (*MetricCardinality, error) {
+
+	metrics, err := sc.findMetricRefs(ctx, node, accountID, query, start,
+		end)
+	if err != nil {
+		return nil, err
+	}
+	ring, err := sc.fetchMetricRing(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+	perNode, err := ring.distribution(metrics, sc.metricName)
+	if err != nil {
+		return nil, err
+	}
+	var sum int
+	for _, n := range perNode {
+		sum += n
+	}
+	mc := &MetricCardinality{PerNode: perNode}
+	if ring.copies > 0 {
+		mc.Total = sum / ring.copies
+	}
+	return mc, nil
+}
@@ -0,0 +1,106 @@
+package gosnowth
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricDistribution(t *testing.T) {
+	ts := newTestServer(ringTestHandler)
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	dist, err := sc.MetricDistribution(node,
+		MetricRef{ID: ringTestUUID, Metric: "a"},
+		MetricRef{ID: ringTestUUID, Metric: "b"},
+		MetricRef{ID: ringTestUUID, Metric: "d"})
+	if err != nil {
+		t.Fatal("error getting distribution: ", err)
+	}
+	assert.Equal(t, map[string]int{
+		"aaaaaaaa-0000-0000-0000-000000000000": 2,
+		"bbbbbbbb-0000-0000-0000-000000000000": 3,
+		"cccccccc-0000-0000-0000-000000000000": 1,
+	}, dist, "each copy of each metric should be counted")
+}
+
+func TestGetMetricCardinality(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/find/1/tags" {
+			w.Write([]byte(`[
+				{"uuid":"` + ringTestUUID + `","metric_name":"a"},
+				{"uuid":"` + ringTestUUID + `","metric_name":"b"},
+				{"uuid":"` + ringTestUUID + `","metric_name":"d"}
+			]`))
+			return
+		}
+		ringTestHandler(w, r)
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	mc, err := sc.GetMetricCardinality(node, 1, "and(__name:*)",
+		time.Unix(1380000000, 0), time.Unix(1380086400, 0))
+	if err != nil {
+		t.Fatal("error getting cardinality: ", err)
+	}
+	assert.Equal(t, map[string]int{
+		"aaaaaaaa-0000-0000-0000-000000000000": 2,
+		"bbbbbbbb-0000-0000-0000-000000000000": 3,
+		"cccccccc-0000-0000-0000-000000000000": 1,
+	}, mc.PerNode, "each node should count its copies")
+	assert.Equal(t, 3, mc.Total, "replicated metrics should count once")
+}
+
+func TestMetricDistributionPrefix(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/find/1/tags" {
+			w.Write([]byte(`[{"uuid":"` + ringTestUUID +
+				`","metric_name":"acme.a"}]`))
+			return
+		}
+		ringTestHandler(w, r)
+	})
+	defer ts.Close()
+	sc, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithMetricPrefix("acme."))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+	node := sc.ListActiveNodes()[0]
+
+	ring := newTestRing(t)
+	owners, err := ring.owners(ringTestUUID, "acme.a")
+	if err != nil {
+		t.Fatal("error finding owners: ", err)
+	}
+	unprefixed, err := ring.owners(ringTestUUID, "a")
+	if err != nil {
+		t.Fatal("error finding owners: ", err)
+	}
+	assert.NotEqual(t, unprefixed, owners,
+		"the prefix should move the metric")
+	exp := make(map[string]int)
+	for _, id := range owners {
+		exp[id]++
+	}
+
+	dist, err := sc.MetricDistribution(node,
+		MetricRef{ID: ringTestUUID, Metric: "a"})
+	if err != nil {
+		t.Fatal("error getting distribution: ", err)
+	}
+	assert.Equal(t, exp, dist, "metrics should be located by stored name")
+
+	dist, err = sc.TagQueryDistribution(node, 1, "and(__name:a)",
+		time.Unix(1380000000, 0), time.Unix(1380086400, 0))
+	if err != nil {
+		t.Fatal("error getting distribution: ", err)
+	}
+	assert.Equal(t, exp, dist,
+		"found metrics should be located by stored name")
+}
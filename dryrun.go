@@ -0,0 +1,43 @@
+package gosnowth
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DryRunRequest - a request which would have been sent to a node, had the
+// client not been in dry-run mode
+type DryRunRequest struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+// WithDryRun - put the client in dry-run mode, for validating ingest
+// pipelines without mutating data.  Writes of data, and deletes, are encoded
+// and validated as usual, then passed to the function given instead of being
+// sent, and return without error.  Snowth has no validate-only write
+// parameter, so nothing is sent to the node.  All other requests, including
+// reads made with POST, such as ReadNNTMulti, are performed as usual.
+func WithDryRun(fn func(DryRunRequest)) ClientOption {
+	return func(sc *SnowthClient) error {
+		sc.dryRun = fn
+		return nil
+	}
+}
+
+// isDryRunRequest - whether a request of the reference given writes or
+// deletes data, and so is not sent in dry-run mode
+func isDryRunRequest(method, ref string) bool {
+	if method == "DELETE" {
+		return true
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(u.Path, "/write/") || u.Path == "/raw" ||
+		u.Path == "/histogram/write"
+}
@@ -0,0 +1,81 @@
+package gosnowth
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDryRun(t *testing.T) {
+	var writes, reads int
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" || r.Method == "HEAD":
+			reads++
+			w.Write([]byte("[]"))
+		case r.URL.Path == "/fetch":
+			reads++
+			w.Write([]byte(`{"version":"DF4",` +
+				`"head":{"count":1,"start":1380000000,"period":60},` +
+				`"meta":[{"kind":"numeric","label":"pass"}],"data":[[1]]}`))
+		default:
+			writes++
+		}
+	})
+	defer ts.Close()
+
+	var requests []DryRunRequest
+	sc, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithDryRun(func(r DryRunRequest) {
+			requests = append(requests, r)
+		}))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+	node := sc.ListActiveNodes()[0]
+
+	if err := sc.WriteNNT(node, NNTData{
+		ID: "id", Metric: "metric", Offset: 1380000000, Count: 1,
+	}); err != nil {
+		t.Fatal("error writing nnt data: ", err)
+	}
+	assert.Equal(t, 0, writes, "no write should be sent in dry-run mode")
+	if assert.Equal(t, 1, len(requests)) {
+		assert.Equal(t, "POST", requests[0].Method)
+		assert.Equal(t, ts.URL+"/write/nnt", requests[0].URL)
+		var data []map[string]interface{}
+		if err := json.Unmarshal(requests[0].Body, &data); err != nil {
+			t.Fatal("invalid dry-run body: ", err)
+		}
+		assert.Equal(t, "metric", data[0]["metric"],
+			"the encoded write should be reported")
+	}
+
+	_, err = sc.ReadNNTValues(node, time.Now(), time.Now(), 60, "count",
+		"id", "metric")
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, 1, reads, "reads should still be sent in dry-run mode")
+
+	values, err := sc.ReadNNTMulti(node, time.Unix(1380000000, 0),
+		time.Unix(1380000000, 0), 60, NNTAverage,
+		[]MetricRef{{ID: "id", Metric: "metric"}})
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, 1, len(values), "posted reads should be sent")
+	assert.Equal(t, 2, reads)
+
+	assert.NoError(t, sc.DeleteMetric(node, "id", "metric"))
+	assert.Equal(t, 0, writes, "no delete should be sent in dry-run mode")
+	assert.Equal(t, 2, len(requests))
+
+	assert.NoError(t, sc.Ping(node))
+	ts.Close()
+	assert.Error(t, sc.Ping(node), "probes should be sent in dry-run mode")
+}
@@ -0,0 +1,156 @@
+package gosnowth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrBackpressure - the cause of an error response from a node which is
+// overloaded and asking clients to slow down, such as a 429 Too Many Requests
+// response.  Match it with errors.Is, as the error returned is a SnowthError
+// wrapping it.
+var ErrBackpressure = errors.New("node is applying backpressure")
+
+// ErrMetricNotFound - the cause of an error response from a node to a read
+// or delete of a metric it does not know, a 404 Not Found response.  Match it with
+// errors.Is, as the error returned is a SnowthError wrapping it.  A read of
+// a known metric with no data in the window read is not an error, and
+// returns no values.
+var ErrMetricNotFound = errors.New("metric not found")
+
+// SnowthError - an error response returned by a node.  Snowth reports
+// errors with a JSON body holding error and message fields, which are parsed
+// into the error when present.  When the body is not in this form, it is
+// kept only as raw text.  Inspect an error returned by the client with
+// errors.As to tell apart, for instance, a 404 from a node holding no data
+// and a 503 from an overloaded node.
+type SnowthError struct {
+	Status     string
+	StatusCode int
+	Body       string
+
+	// NodeURL is the URL of the node which responded, and Path is the path
+	// of the request it responded to.
+	NodeURL string
+	Path    string
+
+	// ErrorText and Message are parsed from the body, if it is a snowth
+	// JSON error response.
+	ErrorText string
+	Message   string
+}
+
+// errorResponse - the JSON form of a snowth error response body
+type errorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+// checkResponse - check the status of a response from a node, returning a
+// SnowthError for any status other than 2xx, after consuming and closing the
+// body of the response
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	se := newSnowthError(resp.Status, resp.StatusCode, body)
+	if req := resp.Request; req != nil && req.URL != nil {
+		se.NodeURL = (&url.URL{Scheme: req.URL.Scheme,
+			Host: req.URL.Host}).String()
+		se.Path = req.URL.Path
+	}
+	return se
+}
+
+// newSnowthError - create an error from a non-success response
+func newSnowthError(status string, statusCode int, body []byte) *SnowthError {
+	se := &SnowthError{
+		Status:     status,
+		StatusCode: statusCode,
+		Body:       string(body),
+	}
+	var er errorResponse
+	if err := json.Unmarshal(body, &er); err == nil {
+		se.ErrorText = er.Error
+		se.Message = er.Message
+	}
+	return se
+}
+
+// Error - the description of the error, using the parsed error fields when
+// available, and the raw body otherwise
+func (se *SnowthError) Error() string {
+	detail := se.Body
+	if se.ErrorText != "" || se.Message != "" {
+		var parts []string
+		for _, s := range []string{se.ErrorText, se.Message} {
+			if s != "" {
+				parts = append(parts, s)
+			}
+		}
+		detail = strings.Join(parts, ": ")
+	}
+	return fmt.Sprintf("non-success status code returned: %s -> %s",
+		se.Status, detail)
+}
+
+// Unwrap - the cause underlying the error response, if it is recognized.
+// This is ErrBackpressure for responses signalling the node is overloaded,
+// and ErrMetricNotFound for reads and deletes of metrics the node does not
+// know.
+func (se *SnowthError) Unwrap() error {
+	switch {
+	case se.StatusCode == http.StatusTooManyRequests:
+		return ErrBackpressure
+	case se.StatusCode == http.StatusNotFound && isMetricPath(se.Path):
+		return ErrMetricNotFound
+	}
+	return nil
+}
+
+// metricPaths - the path segments of the apis reading or deleting the data
+// of a metric
+var metricPaths = []string{"/read/", "/histogram/", "/full/canonical/"}
+
+// isMetricPath - whether a request path, which may be beneath the base path
+// of a node, reads or deletes the data of a metric
+func isMetricPath(p string) bool {
+	for _, rp := range metricPaths {
+		if strings.Contains(p, rp) {
+			return true
+		}
+	}
+	return false
+}
+
+// SeedError - the failure of a seed node given to the client to bootstrap
+type SeedError struct {
+	Addr string
+	Err  error
+}
+
+// BootstrapError - returned in creating a client when no seed node could be
+// activated, listing each seed node and why it failed
+type BootstrapError struct {
+	Seeds []SeedError
+}
+
+// Error - the description of the error, listing the failures of the seeds
+func (be *BootstrapError) Error() string {
+	if len(be.Seeds) == 0 {
+		return "no snowth nodes could be activated: no seed nodes given"
+	}
+	parts := make([]string, 0, len(be.Seeds))
+	for _, s := range be.Seeds {
+		parts = append(parts, s.Addr+": "+s.Err.Error())
+	}
+	return "no snowth nodes could be activated: " + strings.Join(parts, "; ")
+}
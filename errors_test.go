@@ -0,0 +1,109 @@
+package gosnowth
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnowthError(t *testing.T) {
+	var body string
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(body))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	body = `{"error":"read failed","message":"metric name too long"}`
+	_, err := sc.ReadNNTValues(node, time.Now(), time.Now(), 60, "count",
+		"id", "metric")
+	se, ok := err.(*SnowthError)
+	if !ok {
+		t.Fatalf("error should be a SnowthError: %v", err)
+	}
+	assert.Equal(t, http.StatusInternalServerError, se.StatusCode)
+	assert.Equal(t, "read failed", se.ErrorText)
+	assert.Equal(t, "metric name too long", se.Message)
+	assert.Equal(t, "non-success status code returned: "+
+		"500 Internal Server Error -> read failed: metric name too long",
+		se.Error(), "error should use the parsed fields")
+
+	body = "internal error"
+	_, err = sc.ReadNNTValues(node, time.Now(), time.Now(), 60, "count",
+		"id", "metric")
+	assert.Equal(t, "non-success status code returned: "+
+		"500 Internal Server Error -> internal error", err.Error(),
+		"error should fall back to the raw body")
+}
+
+func TestErrBackpressure(t *testing.T) {
+	var status int
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	status = http.StatusTooManyRequests
+	err := sc.WriteNNT(node, NNTData{ID: "id", Metric: "metric", Count: 1})
+	assert.True(t, errors.Is(err, ErrBackpressure),
+		"a 429 response should be backpressure")
+	_, ok := err.(*SnowthError)
+	assert.True(t, ok, "backpressure should still be a SnowthError")
+
+	status = http.StatusInternalServerError
+	err = sc.WriteNNT(node, NNTData{ID: "id", Metric: "metric", Count: 1})
+	assert.False(t, errors.Is(err, ErrBackpressure),
+		"other failures should not be backpressure")
+}
+
+func TestSnowthErrorAs(t *testing.T) {
+	var status int
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	for _, status = range []int{http.StatusNotFound,
+		http.StatusServiceUnavailable} {
+		_, err := sc.ReadTextValues(node, time.Unix(0, 0), time.Unix(0, 0),
+			"id", "metric")
+		var se *SnowthError
+		if !errors.As(errors.Wrap(err, "wrapped"), &se) {
+			t.Fatalf("error should be a SnowthError: %v", err)
+		}
+		assert.Equal(t, status, se.StatusCode)
+		assert.Equal(t, ts.URL, se.NodeURL)
+		assert.Equal(t, "/read/0/0/id/metric", se.Path)
+	}
+
+	status = http.StatusNoContent
+	assert.NoError(t, sc.WriteNNT(node, NNTData{ID: "id", Metric: "metric",
+		Count: 1}), "any 2xx status should be a success")
+}
+
+func TestBootstrapError(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {})
+	addr := ts.URL
+	ts.Close()
+
+	_, err := NewSnowthClientWithOptions(false, []string{addr, "foobar"},
+		WithRetryPolicy(RetryPolicy{}))
+	be, ok := err.(*BootstrapError)
+	if !ok {
+		t.Fatalf("error should be a BootstrapError: %v", err)
+	}
+	if assert.Equal(t, 2, len(be.Seeds)) {
+		assert.Equal(t, addr, be.Seeds[0].Addr)
+		assert.Contains(t, be.Seeds[0].Err.Error(), "failed to get node state")
+		assert.Equal(t, "foobar", be.Seeds[1].Addr)
+		assert.Contains(t, be.Seeds[1].Err.Error(), "invalid seed address")
+	}
+	assert.Contains(t, err.Error(), addr)
+	assert.Contains(t, err.Error(), "foobar")
+}
@@ -0,0 +1,340 @@
+package gosnowth
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// importBatchSize - the number of records written to a node at once when
+// importing exported data.
+const importBatchSize = 1000
+
+// streamFlushInterval - the number of lines written to a stream between
+// flushes of its buffer.
+const streamFlushInterval = 100
+
+// nntValueRecord - the JSON form of an NNT value written to a stream, with
+// the time in seconds since the epoch
+type nntValueRecord struct {
+	Time  int64   `json:"time"`
+	Value float64 `json:"value"`
+}
+
+// ReadNNTValuesTo - read NNT data from a node, as ReadNNTValues does, and
+// stream it to the writer as newline delimited JSON, without building a
+// slice of the values.  Each line is an object holding the time, in seconds
+// since the epoch, and the value.  Output is buffered, and flushed every
+// hundred lines and when the read completes.
+func (sc *SnowthClient) ReadNNTValuesTo(w io.Writer, node *SnowthNode,
+	start, end time.Time, period int64, t, id, metric string) error {
+	return sc.ReadNNTValuesToContext(context.Background(), w, node, start,
+		end, period, t, id, metric)
+}
+
+// ReadNNTValuesToContext - stream NNT data from a node to a writer, as
+// ReadNNTValuesTo does, aborting the read when the context given is done
+func (sc *SnowthClient) ReadNNTValuesToContext(ctx context.Context,
+	w io.Writer, node *SnowthNode, start, end time.Time, period int64,
+	t, id, metric string) error {
+
+	var (
+		bw  = bufio.NewWriter(w)
+		enc = json.NewEncoder(bw)
+		n   = 0
+	)
+	err := sc.ReadNNTValuesFuncContext(ctx, node, start, end, period, t, id,
+		metric, func(v NNTValue) error {
+			if err := enc.Encode(nntValueRecord{
+				Time:  v.Time.Unix(),
+				Value: v.Float,
+			}); err != nil {
+				return errors.Wrap(err, "failed to write nnt value")
+			}
+			if n++; n%streamFlushInterval == 0 {
+				return bw.Flush()
+			}
+			return nil
+		})
+	if ferr := bw.Flush(); err == nil && ferr != nil {
+		err = errors.Wrap(ferr, "failed to flush nnt values")
+	}
+	return err
+}
+
+// ExportMetric - export the raw data of a metric stored on a node, within
+// the window given, streaming it to the writer as newline delimited JSON.
+// Each line is a RawNumericData record, in the form accepted by the raw data
+// submission api, so an export may be restored with ImportMetric.
+func (sc *SnowthClient) ExportMetric(node *SnowthNode, id, metric string,
+	start, end time.Time, w io.Writer) error {
+	return sc.ExportMetricContext(context.Background(), node, id, metric,
+		start, end, w)
+}
+
+// ExportMetricContext - export the raw data of a metric, as ExportMetric does,
+// aborting the read when the context given is done
+func (sc *SnowthClient) ExportMetricContext(ctx context.Context,
+	node *SnowthNode, id, metric string, start, end time.Time,
+	w io.Writer) error {
+
+	enc := json.NewEncoder(w)
+	return sc.readRawNumeric(ctx, node, start, end, id, metric,
+		func(rnd RawNumericData) error {
+			return enc.Encode(rnd)
+		})
+}
+
+// ExportMetricRange - export the raw data of a metric stored on a node, as
+// ExportMetric does, in the same format, but resuming from the offset given,
+// so that an interrupted export can be resumed rather than restarted.  The
+// offset is a position in the node's response, rather than in the export,
+// and is zero to start an export.  The offset following the last record
+// written is returned, including on error, so a later call may resume from
+// it, appending the remaining records.  The remainder of the response is
+// requested with an HTTP range request; if the node does not support ranges
+// and returns the full response, the bytes before the offset are read and
+// discarded.  The window given must be the same for each call.
+func (sc *SnowthClient) ExportMetricRange(node *SnowthNode, id, metric string,
+	start, end time.Time, w io.Writer, offset int64) (int64, error) {
+	return sc.ExportMetricRangeContext(context.Background(), node, id,
+		metric, start, end, w, offset)
+}
+
+// ExportMetricRangeContext - export the raw data of a metric stored on a
+// node, resuming from the offset given, as ExportMetricRange does, with the
+// request bound to the context given.
+func (sc *SnowthClient) ExportMetricRangeContext(ctx context.Context,
+	node *SnowthNode, id, metric string, start, end time.Time, w io.Writer,
+	offset int64) (int64, error) {
+
+	var header http.Header
+	if offset > 0 {
+		header = http.Header{"Range": {fmt.Sprintf("bytes=%d-", offset)}}
+	}
+	ref := rawNumericPath(start, end, id, sc.metricName(metric))
+	resp, err := sc.send(ctx, node, "GET", ref, nil, header)
+	if err != nil || resp == nil {
+		return offset, err
+	}
+	defer resp.Body.Close()
+
+	body := bufio.NewReader(resp.Body)
+	if offset > 0 && resp.StatusCode != http.StatusPartialContent {
+		sc.Logger.Debugf("range not supported by %s, skipping %d bytes",
+			node.GetURL().Host, offset)
+		_, err := io.CopyN(ioutil.Discard, body, offset)
+		if err != nil {
+			return offset, errors.Wrap(err,
+				"failed to skip to export offset")
+		}
+	}
+
+	var (
+		r    io.Reader = body
+		base           = offset
+	)
+	if offset > 0 {
+		// the response resumes after a sample, within the array of
+		// samples, so the separator is skipped and the array reopened
+		n, err := skipRawSeparator(body)
+		if err != nil {
+			return offset, err
+		}
+		r = io.MultiReader(strings.NewReader("["), body)
+		base += int64(n) - 1
+	}
+	dec := json.NewDecoder(r)
+	if _, err := dec.Token(); err != nil {
+		return offset, errors.Wrap(err, "failed to decode raw response")
+	}
+	for dec.More() {
+		rnd, ok, err := decodeRawSample(dec, id, metric)
+		if err != nil {
+			return offset, err
+		}
+		if ok {
+			line, err := json.Marshal(rnd)
+			if err != nil {
+				return offset, errors.Wrap(err,
+					"failed to encode record")
+			}
+			if _, err := w.Write(append(line, '\n')); err != nil {
+				return offset, errors.Wrap(err,
+					"failed to write export")
+			}
+		}
+		offset = base + dec.InputOffset()
+	}
+	return offset, nil
+}
+
+// skipRawSeparator - skip the whitespace and separator following a sample of
+// a raw read, returning the number of bytes skipped
+func skipRawSeparator(r *bufio.Reader) (int, error) {
+	n := 0
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return n, errors.Wrap(err, "failed to read export")
+		}
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			n++
+		case ',':
+			return n + 1, nil
+		default:
+			return n, r.UnreadByte()
+		}
+	}
+}
+
+// ImportOption - an option which alters how an import is performed
+type ImportOption func(*importOptions)
+
+// importOptions - the settings collected from the import options given to
+// ImportMetric
+type importOptions struct {
+	progress func(total int)
+}
+
+// WithImportProgress - call the function given after each batch of records
+// is written by an import, with the total number of records restored so far
+func WithImportProgress(fn func(total int)) ImportOption {
+	return func(imo *importOptions) {
+		imo.progress = fn
+	}
+}
+
+// ImportMetric - restore data exported with ExportMetric.  Each record in
+// the stream is validated, then written to the primary active node owning
+// its metric, as located through the node given.  Progress is logged, and
+// reported to any function given by WithImportProgress, as each batch of
+// records is written, and the total number of records restored is returned.
+// Records written before an error is encountered remain stored.
+func (sc *SnowthClient) ImportMetric(node *SnowthNode, r io.Reader,
+	opts ...ImportOption) (int, error) {
+	return sc.ImportMetricContext(context.Background(), node, r, opts...)
+}
+
+// ImportMetricContext - restore data exported with ExportMetric, as
+// ImportMetric does, aborting the writes when the context given is done
+func (sc *SnowthClient) ImportMetricContext(ctx context.Context,
+	node *SnowthNode, r io.Reader, opts ...ImportOption) (int, error) {
+
+	imo := new(importOptions)
+	for _, opt := range opts {
+		opt(imo)
+	}
+	var (
+		scanner = bufio.NewScanner(r)
+		owners  = make(map[string]*SnowthNode)
+		batches = make(map[*SnowthNode][]RawNumericData)
+		line    = 0
+		total   = 0
+	)
+
+	flush := func(owner *SnowthNode) error {
+		batch := batches[owner]
+		err := sc.WriteRawNumericContext(ctx, owner, batch...)
+		if err != nil {
+			return errors.Wrap(err, "failed to write import batch")
+		}
+		total += len(batch)
+		delete(batches, owner)
+		sc.Logger.Infof("imported %d records, %d written to %s",
+			total, len(batch), owner.GetURL().Host)
+		if imo.progress != nil {
+			imo.progress(total)
+		}
+		return nil
+	}
+
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		rnd, err := decodeExportRecord(scanner.Bytes())
+		if err != nil {
+			return total, errors.Wrapf(err,
+				"invalid export record on line %d", line)
+		}
+
+		key := rnd.ID + "/" + rnd.Metric
+		owner, ok := owners[key]
+		if !ok {
+			if owner, err = sc.locateOwner(ctx, node, rnd.ID,
+				rnd.Metric); err != nil {
+				return total, err
+			}
+			owners[key] = owner
+		}
+
+		batches[owner] = append(batches[owner], rnd)
+		if len(batches[owner]) >= importBatchSize {
+			if err := flush(owner); err != nil {
+				return total, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return total, errors.Wrap(err, "failed to read export stream")
+	}
+
+	for owner := range batches {
+		if err := flush(owner); err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// decodeExportRecord - decode and validate a single line of an export
+func decodeExportRecord(b []byte) (RawNumericData, error) {
+	var rnd RawNumericData
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rnd); err != nil {
+		return rnd, errors.Wrap(err, "failed to decode record")
+	}
+	switch {
+	case rnd.ID == "":
+		return rnd, errors.New("record has no id")
+	case rnd.Metric == "":
+		return rnd, errors.New("record has no metric")
+	case rnd.Offset <= 0:
+		return rnd, fmt.Errorf("record has invalid offset: %d", rnd.Offset)
+	}
+	return rnd, nil
+}
+
+// locateOwner - find the primary active node owning a metric, using the
+// locate api of the node given.  When none of the owning nodes are active in
+// the client, the node given is used, and it is left to the cluster to
+// replicate the data to its owners.
+func (sc *SnowthClient) locateOwner(ctx context.Context, node *SnowthNode,
+	id, metric string) (*SnowthNode, error) {
+
+	location, err := sc.LocateMetricContext(ctx, id, metric, node)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to locate metric")
+	}
+	for _, ln := range location.Nodes {
+		if owner := sc.findActiveNode(ln.ID); owner != nil {
+			return owner, nil
+		}
+	}
+	sc.Logger.Warnf("no active owning node for metric, using %s: %s %s",
+		node.GetURL().Host, id, metric)
+	return node, nil
+}
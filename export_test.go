@@ -0,0 +1,203 @@
+package gosnowth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportMetric(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/raw/id/metric", r.URL.Path, "should be a raw read")
+		assert.Equal(t, "1380000000", r.URL.Query().Get("start_ts"))
+		assert.Equal(t, "1380000060", r.URL.Query().Get("end_ts"))
+		w.Write([]byte(`[[1380000000000,1.5],[1380000000500,2],
+			[1380000001000,null]]`))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	buf := new(bytes.Buffer)
+	err := sc.ExportMetric(node, "id", "metric", time.Unix(1380000000, 0),
+		time.Unix(1380000060, 0), buf)
+	if err != nil {
+		t.Fatal("error exporting metric: ", err)
+	}
+	assert.Equal(t,
+		`{"metric":"metric","id":"id","offset":1380000000000,"value":1.5}`+"\n"+
+			`{"metric":"metric","id":"id","offset":1380000000500,"value":2}`+"\n",
+		buf.String(), "export should contain each recorded sample")
+}
+
+func TestImportMetric(t *testing.T) {
+	var (
+		stored  = []RawNumericData{}
+		written = 0
+	)
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/locate/xml/"):
+			assert.Equal(t, "/locate/xml/id/tenant.metric",
+				r.URL.Path, "the prefixed metric should be located")
+			fmt.Fprintf(w, `<nodes n="1"><node id="%s" address="%s"
+				port="8112" apiport="8112" weight="32"/></nodes>`,
+				"bb6f7162-4828-11df-bab8-6bac200dcc2a", "localhost")
+		case r.Method == "POST" && r.URL.Path == "/raw":
+			data := []RawNumericData{}
+			if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+				t.Error("invalid raw write: ", err)
+			}
+			assert.Equal(t, strconv.Itoa(len(data)),
+				r.Header.Get("X-Snowth-Datapoints"))
+			for _, rnd := range data {
+				assert.Equal(t, "tenant.metric", rnd.Metric,
+					"imported metrics should be prefixed")
+			}
+			stored = append(stored, data...)
+			written++
+		case strings.HasPrefix(r.URL.Path, "/raw/"):
+			assert.Equal(t, "/raw/id/tenant.metric", r.URL.Path)
+			w.Write([]byte("["))
+			for i, rnd := range stored {
+				if i > 0 {
+					w.Write([]byte(","))
+				}
+				fmt.Fprintf(w, "[%d,%g]", rnd.Offset, rnd.Value)
+			}
+			w.Write([]byte("]"))
+		}
+	})
+	defer ts.Close()
+	sc, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithMetricPrefix("tenant."))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+	node := sc.ListActiveNodes()[0]
+
+	export := `{"metric":"metric","id":"id","offset":1380000000000,"value":1.5}
+{"metric":"metric","id":"id","offset":1380000000500,"value":2}
+`
+	progress := []int{}
+	n, err := sc.ImportMetric(node, strings.NewReader(export),
+		WithImportProgress(func(total int) {
+			progress = append(progress, total)
+		}))
+	if err != nil {
+		t.Fatal("error importing metric: ", err)
+	}
+	assert.Equal(t, 2, n, "should import each record")
+	assert.Equal(t, 1, written, "records should be written in one batch")
+	assert.Equal(t, []int{2}, progress,
+		"progress should be reported after each batch")
+
+	buf := new(bytes.Buffer)
+	err = sc.ExportMetric(node, "id", "metric", time.Unix(1380000000, 0),
+		time.Unix(1380000060, 0), buf)
+	if err != nil {
+		t.Fatal("error exporting metric: ", err)
+	}
+	assert.Equal(t, export, buf.String(), "imported data should be readable")
+
+	_, err = sc.ImportMetric(node, strings.NewReader(
+		`{"metric":"metric","offset":1380000000000,"value":1.5}`))
+	assert.Error(t, err, "record without an id should be rejected")
+	_, err = sc.ImportMetric(node, strings.NewReader("not json"))
+	assert.Error(t, err, "malformed stream should be rejected")
+}
+
+func TestReadNNTValuesTo(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[[1380000000,1.5],[1380000060,2]]"))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	buf := new(bytes.Buffer)
+	if err := sc.ReadNNTValuesTo(buf, node, time.Unix(1380000000, 0),
+		time.Unix(1380000060, 0), 60, "average", "id", "metric"); err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, `{"time":1380000000,"value":1.5}`+"\n"+
+		`{"time":1380000060,"value":2}`+"\n", buf.String(),
+		"values should be written as json lines")
+}
+
+func TestExportMetricRange(t *testing.T) {
+	const data = "[[1380000000000,1],\n[1380000000500,null],\n" +
+		"[1380000001000,2]]"
+	var ranges bool
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if ranges {
+			http.ServeContent(w, r, "", time.Time{},
+				strings.NewReader(data))
+			return
+		}
+		w.Write([]byte(data))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	defer sc.Close()
+
+	const (
+		first = `{"metric":"metric","id":"id",` +
+			`"offset":1380000000000,"value":1}` + "\n"
+		second = `{"metric":"metric","id":"id",` +
+			`"offset":1380000001000,"value":2}` + "\n"
+	)
+	for _, ranges = range []bool{true, false} {
+		// the first attempt is interrupted after the first record
+		buf := new(bytes.Buffer)
+		offset, err := sc.ExportMetricRange(node, "id", "metric",
+			time.Unix(1380000000, 0), time.Unix(1380000002, 0),
+			&limitedWriter{w: buf, n: len(first)}, 0)
+		assert.Error(t, err, "the export should be interrupted")
+		assert.Equal(t, first, buf.String())
+
+		offset, err = sc.ExportMetricRange(node, "id", "metric",
+			time.Unix(1380000000, 0), time.Unix(1380000002, 0), buf,
+			offset)
+		if err != nil {
+			t.Fatal("error resuming export: ", err)
+		}
+		assert.Equal(t, int64(len(data)-1), offset,
+			"the offset should follow the last sample")
+		assert.Equal(t, first+second, buf.String(),
+			"the resumed export should complete the records")
+
+		// exports resumed at the end are empty
+		offset, err = sc.ExportMetricRange(node, "id", "metric",
+			time.Unix(1380000000, 0), time.Unix(1380000002, 0), buf,
+			offset)
+		if err != nil {
+			t.Fatal("error resuming export: ", err)
+		}
+		assert.Equal(t, int64(len(data)-1), offset)
+		assert.Equal(t, first+second, buf.String())
+	}
+}
+
+// limitedWriter - a writer which fails after n bytes are written
+type limitedWriter struct {
+	w io.Writer
+	n int
+}
+
+func (lw *limitedWriter) Write(p []byte) (int, error) {
+	if len(p) > lw.n {
+		n, _ := lw.w.Write(p[:lw.n])
+		lw.n = 0
+		return n, io.ErrShortWrite
+	}
+	lw.n -= len(p)
+	return lw.w.Write(p)
+}
@@ -0,0 +1,96 @@
+package gosnowth
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// noFailoverKey - the context key marking requests which must not fail over
+// to another node
+type noFailoverKey struct{}
+
+// withoutFailover - a context under which requests are made only of the node
+// they are sent to, without failing over to other nodes, so that the node
+// which served a request is known
+func withoutFailover(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noFailoverKey{}, true)
+}
+
+// failoverDisabled - whether requests made under a context must not fail
+// over to another node
+func failoverDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(noFailoverKey{}).(bool)
+	return disabled
+}
+
+// ReadNNTValuesAny - read NNT data, as ReadNNTValues does, from any active
+// node owning the metric, returning the node the data was read from.  The
+// owners are tried in the order chosen by the node selector of the client,
+// set with WithNodeSelector, round-robin by default, until one of them
+// responds, and an error listing the owners is returned if none of them are
+// active.  Each owner is read without failing over to other nodes, so that
+// the node returned is the one which served the read.
+func (sc *SnowthClient) ReadNNTValuesAny(start, end time.Time, period int64,
+	t, id, metric string, opts ...ReadOption) ([]NNTValue, *SnowthNode, error) {
+	return sc.ReadNNTValuesAnyContext(context.Background(), start, end,
+		period, t, id, metric, opts...)
+}
+
+// ReadNNTValuesAnyContext - read NNT data from any owner of a metric, as
+// ReadNNTValuesAny does, aborting the reads when the context given is done
+func (sc *SnowthClient) ReadNNTValuesAnyContext(ctx context.Context,
+	start, end time.Time, period int64, t, id, metric string,
+	opts ...ReadOption) ([]NNTValue, *SnowthNode, error) {
+
+	nodes, _, err := sc.NodesForMetricContext(ctx, id, metric)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx = withoutFailover(ctx)
+	mErr := newMultiError()
+	for _, n := range sc.selectNodes(nodes) {
+		values, err := sc.ReadNNTValuesContext(ctx, n, start, end, period, t,
+			id, metric, opts...)
+		if err == nil {
+			return values, n, nil
+		}
+		mErr.Add(errors.Wrapf(err, "failed to read from %s",
+			n.GetURL().Host))
+	}
+	return nil, nil, mErr
+}
+
+// ReadTextValuesAny - read text data, as ReadTextValues does, from any
+// active node owning the metric, returning the node the data was read from,
+// as ReadNNTValuesAny does.
+func (sc *SnowthClient) ReadTextValuesAny(start, end time.Time,
+	id, metric string, opts ...ReadOption) ([]TextValue, *SnowthNode, error) {
+	return sc.ReadTextValuesAnyContext(context.Background(), start, end, id,
+		metric, opts...)
+}
+
+// ReadTextValuesAnyContext - read text data from any owner of a metric, as
+// ReadTextValuesAny does, aborting the reads when the context given is done
+func (sc *SnowthClient) ReadTextValuesAnyContext(ctx context.Context,
+	start, end time.Time, id, metric string,
+	opts ...ReadOption) ([]TextValue, *SnowthNode, error) {
+
+	nodes, _, err := sc.NodesForMetricContext(ctx, id, metric)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx = withoutFailover(ctx)
+	mErr := newMultiError()
+	for _, n := range sc.selectNodes(nodes) {
+		values, err := sc.ReadTextValuesContext(ctx, n, start, end, id,
+			metric, opts...)
+		if err == nil {
+			return values, n, nil
+		}
+		mErr.Add(errors.Wrapf(err, "failed to read from %s",
+			n.GetURL().Host))
+	}
+	return nil, nil, mErr
+}
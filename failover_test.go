@@ -0,0 +1,126 @@
+package gosnowth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadNNTValuesAny(t *testing.T) {
+	newServer := func(identity string, ok bool) *httptest.Server {
+		return newRingNodeTestServer(identity, 2,
+			func(w http.ResponseWriter, r *http.Request) {
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				if strings.HasPrefix(r.URL.Path, "/read/") {
+					w.Write([]byte("[[1380000000,1]]"))
+				}
+			})
+	}
+	var addrs []string
+	for _, s := range []struct {
+		id string
+		ok bool
+	}{
+		{"aaaaaaaa-0000-0000-0000-000000000000", true},
+		{"bbbbbbbb-0000-0000-0000-000000000000", false},
+		{"cccccccc-0000-0000-0000-000000000000", true},
+	} {
+		ts := newServer(s.id, s.ok)
+		defer ts.Close()
+		addrs = append(addrs, ts.URL)
+	}
+	sc, err := NewSnowthClient(false, addrs...)
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+
+	// the owners of metric "a" are bbbbbbbb then cccccccc
+	values, node, err := sc.ReadNNTValuesAny(time.Unix(1380000000, 0),
+		time.Unix(1380000300, 0), 300, "count", ringTestUUID, "a")
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, 1, len(values))
+	assert.Equal(t, "cccccccc-0000-0000-0000-000000000000", node.identifier,
+		"the read should fail over to the next owner")
+
+	for _, n := range sc.ListActiveNodes() {
+		if n.identifier != "aaaaaaaa-0000-0000-0000-000000000000" {
+			sc.DeactivateNodes(n)
+		}
+	}
+	_, _, err = sc.ReadTextValuesAny(time.Unix(1380000000, 0),
+		time.Unix(1380000300, 0), ringTestUUID, "a")
+	if assert.Error(t, err, "a read with no active owners should fail") {
+		assert.Contains(t, err.Error(),
+			"bbbbbbbb-0000-0000-0000-000000000000, "+
+				"cccccccc-0000-0000-0000-000000000000")
+	}
+}
+
+func TestReadNNTValuesAnyServingNode(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		reads = make(map[string]int)
+	)
+	newServer := func(identity string, status int) *httptest.Server {
+		return newRingNodeTestServer(identity, 2,
+			func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasPrefix(r.URL.Path, "/read/") {
+					return
+				}
+				mu.Lock()
+				reads[identity]++
+				mu.Unlock()
+				if status != http.StatusOK {
+					w.WriteHeader(status)
+					return
+				}
+				w.Write([]byte("[[1380000000,1]]"))
+			})
+	}
+	var addrs []string
+	for _, s := range []struct {
+		id     string
+		status int
+	}{
+		{"aaaaaaaa-0000-0000-0000-000000000000", http.StatusOK},
+		{"bbbbbbbb-0000-0000-0000-000000000000",
+			http.StatusServiceUnavailable},
+		{"cccccccc-0000-0000-0000-000000000000", http.StatusOK},
+	} {
+		ts := newServer(s.id, s.status)
+		defer ts.Close()
+		addrs = append(addrs, ts.URL)
+	}
+	sc, err := NewSnowthClientWithOptions(false, addrs,
+		WithNodeSelector(NewOwnerOrderSelector()),
+		WithRetryPolicy(RetryPolicy{}))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+
+	// the owners of metric "a" are bbbbbbbb then cccccccc, and the failure
+	// of bbbbbbbb would fail over to any active node
+	_, node, err := sc.ReadNNTValuesAny(time.Unix(1380000000, 0),
+		time.Unix(1380000300, 0), 300, "count", ringTestUUID, "a")
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, "cccccccc-0000-0000-0000-000000000000", node.identifier,
+		"the node returned should be the one which served the read")
+	assert.Equal(t, map[string]int{
+		"bbbbbbbb-0000-0000-0000-000000000000": 1,
+		"cccccccc-0000-0000-0000-000000000000": 1,
+	}, reads, "only the owners should be read from")
+}
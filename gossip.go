@@ -1,13 +1,31 @@
 package gosnowth
 
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
 // GetGossipInfo - Get the gossip information from the client.  The gossip
 // response body will include a list of "GossipDetail" which provide
 // the identifier of the node, the node's gossip_time, gossip_age, as well
 // as topology state, current and next topology.  This gossip information is
 // useful to know because you can get availablility information about the node
 func (sc *SnowthClient) GetGossipInfo(node *SnowthNode) (gossip *Gossip, err error) {
+	return sc.GetGossipInfoContext(context.Background(), node)
+}
+
+// GetGossipInfoContext - get the gossip information, as GetGossipInfo does,
+// aborting the request when the context given is done
+func (sc *SnowthClient) GetGossipInfoContext(ctx context.Context,
+	node *SnowthNode) (gossip *Gossip, err error) {
 	gossip = new(Gossip)
-	err = sc.do(node, "GET", "/gossip/json", nil, gossip, decodeJSONFromResponse)
+	err = sc.doContext(ctx, node, "GET", "/gossip/json", nil, gossip,
+		decodeJSONFromResponse)
 	return
 }
 
@@ -16,7 +34,8 @@ func (sc *SnowthClient) GetGossipInfo(node *SnowthNode) (gossip *Gossip, err err
 // nodes are behind with each other with regards to data replication.
 type Gossip []GossipDetail
 
-// GossipDetail - Gossip information about a node identified by ID
+// GossipDetail - Gossip information about a node identified by ID.  See
+// GossipEntry, returned by GetGossip, for the full record of each node.
 type GossipDetail struct {
 	ID          string        `json:"id"`
 	Time        float64       `json:"gossip_time,string"`
@@ -29,3 +48,127 @@ type GossipDetail struct {
 
 // GossipLatency - a map of the uuid of the node to the latency in seconds
 type GossipLatency map[string]string
+
+// GossipEntry - the full gossip record of a node, as seen by the node the
+// gossip was read from.  Numeric fields are accepted as numbers or as the
+// strings snowth reports them as, and fields the client does not model are
+// kept in Fields.
+type GossipEntry struct {
+	// ID is the uuid of the node.
+	ID string
+	// Time is when the node last gossiped, in seconds since the epoch, and
+	// Age is how many seconds ago that was.  A large age indicates the node
+	// is down or cannot be reached.
+	Time float64
+	Age  float64
+	// Port is the port the node gossips on.
+	Port int
+	// CurrentTopo is the hash of the topology the node is using, and
+	// NextTopo the hash of the topology it is moving to, or "-" if none.
+	CurrentTopo string
+	NextTopo    string
+	// TopoState is the state of a change of topology by the node, or "n/a"
+	// if none is in progress.
+	TopoState string
+	// Suspect is whether the node is suspected by its peers of being down.
+	Suspect bool
+	// Latency is the latency in seconds to each peer, by node uuid.
+	Latency map[string]float64
+	// Fields are the fields of the record which are not modelled above.
+	Fields map[string]interface{}
+}
+
+// UnmarshalJSON - decode a gossip record into a gossip entry
+func (ge *GossipEntry) UnmarshalJSON(b []byte) error {
+	fields := map[string]interface{}{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return errors.Wrap(err, "failed to decode gossip entry")
+	}
+	*ge = GossipEntry{Latency: map[string]float64{}}
+	var err error
+	for k, v := range fields {
+		switch k {
+		case "id":
+			ge.ID, _ = v.(string)
+		case "gossip_time":
+			ge.Time, err = gossipFloat(v)
+		case "gossip_age":
+			ge.Age, err = gossipFloat(v)
+		case "port":
+			var f float64
+			f, err = gossipFloat(v)
+			ge.Port = int(f)
+		case "topo_current":
+			ge.CurrentTopo, _ = v.(string)
+		case "topo_next":
+			ge.NextTopo, _ = v.(string)
+		case "topo_state":
+			ge.TopoState, _ = v.(string)
+		case "suspect":
+			ge.Suspect, err = gossipBool(v)
+		case "latency":
+			latency, _ := v.(map[string]interface{})
+			for id, l := range latency {
+				if ge.Latency[id], err = gossipFloat(l); err != nil {
+					break
+				}
+			}
+		default:
+			if ge.Fields == nil {
+				ge.Fields = map[string]interface{}{}
+			}
+			ge.Fields[k] = v
+		}
+		if err != nil {
+			return errors.Wrapf(err, "invalid gossip field %s", k)
+		}
+	}
+	return nil
+}
+
+// gossipFloat - parse a gossip number, given as a number or a string
+func gossipFloat(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case json.Number:
+		return n.Float64()
+	case string:
+		return strconv.ParseFloat(n, 64)
+	case nil:
+		return 0, nil
+	}
+	return 0, fmt.Errorf("invalid number: %v", v)
+}
+
+// gossipBool - parse a gossip flag, given as a boolean, a number or a string
+func gossipBool(v interface{}) (bool, error) {
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case json.Number:
+		return b.String() != "0", nil
+	case string:
+		return strconv.ParseBool(b)
+	case nil:
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid flag: %v", v)
+}
+
+// GetGossip - get the gossip records of a node, describing its view of each
+// of its peers in full, for diagnosing the state of a cluster.
+func (sc *SnowthClient) GetGossip(node *SnowthNode) ([]GossipEntry, error) {
+	return sc.GetGossipContext(context.Background(), node)
+}
+
+// GetGossipContext - get the gossip records of a node, as GetGossip does,
+// aborting the request when the context given is done
+func (sc *SnowthClient) GetGossipContext(ctx context.Context,
+	node *SnowthNode) ([]GossipEntry, error) {
+
+	entries := []GossipEntry{}
+	err := sc.doContext(ctx, node, "GET", "/gossip/json", nil, &entries,
+		decodeJSONFromResponse)
+	return entries, err
+}
@@ -3,6 +3,7 @@ package gosnowth
 import (
 	"bytes"
 	"encoding/json"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -22,3 +23,68 @@ func TestGossipDeserialization(t *testing.T) {
 	assert.Equal(t, 1409082055.744880, []GossipDetail(*gossip)[0].Time, "time should be")
 	assert.Equal(t, 0.0, []GossipDetail(*gossip)[0].Age, "age should be")
 }
+
+func TestGetGossip(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"1f846f26-0cfd-4df5-b4f1-e0930604e577",` +
+			`"gossip_time":"1409082055.744880","gossip_age":2.5,` +
+			`"port":"8112","topo_current":"abc","topo_next":"-",` +
+			`"topo_state":"n/a","suspect":1,"reachable":true,` +
+			`"latency":{"765ac4cc-1929-4642-9ef1-d194d08f9538":"0.25"}}]`))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	gossip, err := sc.GetGossip(node)
+	if err != nil {
+		t.Fatal("error getting gossip: ", err)
+	}
+	assert.Equal(t, []GossipEntry{{
+		ID:          "1f846f26-0cfd-4df5-b4f1-e0930604e577",
+		Time:        1409082055.744880,
+		Age:         2.5,
+		Port:        8112,
+		CurrentTopo: "abc",
+		NextTopo:    "-",
+		TopoState:   "n/a",
+		Suspect:     true,
+		Latency: map[string]float64{
+			"765ac4cc-1929-4642-9ef1-d194d08f9538": 0.25,
+		},
+		Fields: map[string]interface{}{"reachable": true},
+	}}, gossip)
+
+	entries := []GossipEntry{}
+	if err := json.Unmarshal([]byte(gossipTestData), &entries); err != nil {
+		t.Fatal("failed to decode gossip data: ", err)
+	}
+	assert.Equal(t, 4, len(entries))
+}
+
+func TestGetGossipFixture(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(gossipTestData))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	defer sc.Close()
+
+	gossip, err := sc.GetGossip(node)
+	if err != nil {
+		t.Fatal("error getting gossip: ", err)
+	}
+	assert.Equal(t, 4, len(gossip), "should have 4 entries")
+	assert.Equal(t, GossipEntry{
+		ID:   "1f846f26-0cfd-4df5-b4f1-e0930604e577",
+		Time: 1409082055.744880,
+		CurrentTopo: "0123456789abcdef0123456789abcdef" +
+			"0123456789abcdef0123456789abcdef",
+		NextTopo:  "-",
+		TopoState: "n/a",
+		Latency: map[string]float64{
+			"765ac4cc-1929-4642-9ef1-d194d08f9538": 0,
+			"8c2fc7b8-c569-402d-a393-db433fb267aa": 0,
+			"07fa2237-5744-4c28-a622-a99cfc1ac87e": 0,
+		},
+	}, gossip[0])
+}
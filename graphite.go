@@ -0,0 +1,80 @@
+package gosnowth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// GraphiteDatapoint - a value of a Graphite series at a point in time.
+// Value is nil where the series has a gap, which Graphite reports as null.
+type GraphiteDatapoint struct {
+	Time  time.Time
+	Value *float64
+}
+
+// GraphiteSeries - a series rendered for a Graphite target, named as
+// Graphite names it
+type GraphiteSeries struct {
+	Target     string
+	Datapoints []GraphiteDatapoint
+}
+
+// graphiteRenderResponse - the JSON form of a Graphite render response,
+// with each datapoint a tuple of value and time
+type graphiteRenderResponse []struct {
+	Target     string       `json:"target"`
+	Datapoints [][]*float64 `json:"datapoints"`
+}
+
+// GraphiteRender - render a Graphite target, such as
+// "sumSeries(web.*.requests)", over the window given, from the Graphite
+// compatible api of a node, for the account given.  This allows queries
+// written for Graphite dashboards to be used without rewriting them in
+// CAQL.  Gaps in a series are kept, as datapoints with a nil value.
+func (sc *SnowthClient) GraphiteRender(node *SnowthNode, accountID int32,
+	target string, from, until time.Time) ([]GraphiteSeries, error) {
+	return sc.GraphiteRenderContext(context.Background(), node, accountID,
+		target, from, until)
+}
+
+// GraphiteRenderContext - render a graphite query, as GraphiteRender does,
+// aborting the request when the context given is done
+func (sc *SnowthClient) GraphiteRenderContext(ctx context.Context,
+	node *SnowthNode, accountID int32, target string,
+	from, until time.Time) ([]GraphiteSeries, error) {
+
+	q := url.Values{}
+	q.Set("target", target)
+	q.Set("from", strconv.FormatInt(from.Unix(), 10))
+	q.Set("until", strconv.FormatInt(until.Unix(), 10))
+	q.Set("format", "json")
+
+	r := graphiteRenderResponse{}
+	if err := sc.doContext(ctx, node, "GET", fmt.Sprintf(
+		"/graphite/%d/render?%s", accountID, q.Encode()), nil, &r,
+		decodeJSONFromResponse); err != nil {
+		return nil, err
+	}
+	result := make([]GraphiteSeries, 0, len(r))
+	for _, s := range r {
+		series := GraphiteSeries{
+			Target:     s.Target,
+			Datapoints: make([]GraphiteDatapoint, 0, len(s.Datapoints)),
+		}
+		for _, dp := range s.Datapoints {
+			if len(dp) < 2 || dp[1] == nil {
+				return nil, fmt.Errorf("graphite datapoint of %s should "+
+					"contain a value and a time", s.Target)
+			}
+			series.Datapoints = append(series.Datapoints, GraphiteDatapoint{
+				Time:  time.Unix(int64(*dp[1]), 0),
+				Value: dp[0],
+			})
+		}
+		result = append(result, series)
+	}
+	return result, nil
+}
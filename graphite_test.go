@@ -0,0 +1,39 @@
+package gosnowth
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphiteRender(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/graphite/1/render", r.URL.Path)
+		q := r.URL.Query()
+		assert.Equal(t, "sumSeries(web.*.requests)", q.Get("target"))
+		assert.Equal(t, "1380000000", q.Get("from"))
+		assert.Equal(t, "1380000120", q.Get("until"))
+		assert.Equal(t, "json", q.Get("format"))
+		w.Write([]byte(`[{"target":"sumSeries(web.*.requests)",
+			"datapoints":[[1.5,1380000000],[null,1380000060]]}]`))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	defer sc.Close()
+
+	series, err := sc.GraphiteRender(node, 1, "sumSeries(web.*.requests)",
+		time.Unix(1380000000, 0), time.Unix(1380000120, 0))
+	if err != nil {
+		t.Fatal("error rendering graphite target: ", err)
+	}
+	v := 1.5
+	assert.Equal(t, []GraphiteSeries{{
+		Target: "sumSeries(web.*.requests)",
+		Datapoints: []GraphiteDatapoint{
+			{Time: time.Unix(1380000000, 0), Value: &v},
+			{Time: time.Unix(1380000060, 0)},
+		},
+	}}, series, "gaps should be kept with nil values")
+}
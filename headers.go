@@ -0,0 +1,62 @@
+package gosnowth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// RequestIDHeader - the header in which the correlation id of a request is
+// sent, so that the access logs of nodes may be matched to the application
+// requests which caused them
+const RequestIDHeader = "X-Circonus-Request-ID"
+
+// requestIDKey - the context key of the correlation id of requests
+type requestIDKey struct{}
+
+// ContextWithRequestID - return a copy of the context given carrying a
+// correlation id, which is sent in the RequestIDHeader of every request made
+// with the context, such as with ReadNNTValuesContext
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext - the correlation id carried by a context, if any
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// HeaderFunc - a function returning headers to set on a request made with
+// the context given, such as those propagating a trace context
+type HeaderFunc func(ctx context.Context) http.Header
+
+// WithRequestHeaders - set the headers returned by the function given on
+// every request made by the client, before it is signed.  Functions given by
+// multiple options are called in order, with later headers replacing
+// earlier ones of the same name.  Headers the client sets itself for a
+// request, such as its content type, are not replaced.
+func WithRequestHeaders(fn HeaderFunc) ClientOption {
+	return func(sc *SnowthClient) error {
+		if fn == nil {
+			return errors.New("nil header function")
+		}
+		sc.headerFuncs = append(sc.headerFuncs, fn)
+		return nil
+	}
+}
+
+// setRequestHeaders - set the correlation id of the context of a request,
+// and the headers of the header functions of the client, on the request
+func (sc *SnowthClient) setRequestHeaders(r *http.Request) {
+	ctx := r.Context()
+	if id, ok := RequestIDFromContext(ctx); ok {
+		r.Header.Set(RequestIDHeader, id)
+	}
+	for _, fn := range sc.headerFuncs {
+		for k, v := range fn(ctx) {
+			r.Header[http.CanonicalHeaderKey(k)] = v
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package gosnowth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestHeaders(t *testing.T) {
+	var header http.Header
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Write([]byte("[]"))
+	})
+	defer ts.Close()
+	sc, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithRequestHeaders(func(ctx context.Context) http.Header {
+			h := http.Header{}
+			if id, ok := RequestIDFromContext(ctx); ok {
+				h.Set("traceparent", "00-"+id+"-01")
+			}
+			return h
+		}))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+	node := sc.ListActiveNodes()[0]
+
+	ctx := ContextWithRequestID(context.Background(), "abc123")
+	_, err = sc.ReadNNTValuesContext(ctx, node, time.Unix(1380000000, 0),
+		time.Unix(1380000060, 0), 60, "count", "id", "metric")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", header.Get(RequestIDHeader),
+		"the correlation id should be sent")
+	assert.Equal(t, "00-abc123-01", header.Get("Traceparent"),
+		"injected headers should be sent")
+
+	_, err = sc.ReadNNTValues(node, time.Unix(1380000000, 0),
+		time.Unix(1380000060, 0), 60, "count", "id", "metric")
+	assert.NoError(t, err)
+	assert.Empty(t, header.Get(RequestIDHeader))
+
+	_, err = NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithRequestHeaders(nil))
+	assert.Error(t, err)
+}
@@ -0,0 +1,75 @@
+package gosnowth
+
+import (
+	"time"
+)
+
+// NodeHealth - the health of a node known to the client, as last observed by
+// the checks made by the client's watch loop
+type NodeHealth struct {
+	ID     string
+	URL    string
+	Active bool
+
+	// GossipAge is the gossip age of the node at the last check which
+	// observed it, or nil if no check has observed it.
+	GossipAge *float64
+
+	// LastChecked is the time of the last check of the node, or the zero
+	// time if it has not been checked, and LastError is the reason the last
+	// check found the node inactive, or nil if it found it active.
+	LastChecked time.Time
+	LastError   error
+}
+
+// nodeCheck - the outcome of a check of a node
+type nodeCheck struct {
+	age *float64
+	at  time.Time
+	err error
+}
+
+// recordCheck - record the outcome of a check of a node.  The gossip age
+// last observed is kept when the check does not observe one.
+func (sc *SnowthClient) recordCheck(node *SnowthNode, age *float64,
+	err error) {
+	sc.healthMu.Lock()
+	defer sc.healthMu.Unlock()
+	if sc.health == nil {
+		sc.health = make(map[*SnowthNode]nodeCheck)
+	}
+	if age == nil {
+		age = sc.health[node].age
+	}
+	sc.health[node] = nodeCheck{age: age, at: time.Now(), err: err}
+}
+
+// ClusterHealth - report the health of every node known to the client,
+// active nodes first, for dashboards and readiness probes.  Node health is
+// checked periodically by the client's watch loop, and this reports the
+// outcome of the latest check of each node, without contacting any node.
+func (sc *SnowthClient) ClusterHealth() []NodeHealth {
+	var result []NodeHealth
+	add := func(nodes []*SnowthNode, active bool) {
+		for _, node := range nodes {
+			sc.healthMu.Lock()
+			check := sc.health[node]
+			sc.healthMu.Unlock()
+			result = append(result, NodeHealth{
+				ID:          node.identifier,
+				URL:         node.GetURL().String(),
+				Active:      active,
+				GossipAge:   check.age,
+				LastChecked: check.at,
+				LastError:   check.err,
+			})
+		}
+	}
+	sc.activeNodesMu.RLock()
+	add(sc.activeNodes, true)
+	sc.activeNodesMu.RUnlock()
+	sc.inactiveNodesMu.RLock()
+	add(sc.inactiveNodes, false)
+	sc.inactiveNodesMu.RUnlock()
+	return result
+}
@@ -0,0 +1,57 @@
+package gosnowth
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterHealth(t *testing.T) {
+	var age string
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if age == "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(fmt.Sprintf(`[{"id":`+
+			`"bb6f7162-4828-11df-bab8-6bac200dcc2a","gossip_age":"%s"}]`, age)))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	defer sc.Close()
+
+	health := sc.ClusterHealth()
+	if assert.Equal(t, 1, len(health)) {
+		assert.Equal(t, node.identifier, health[0].ID)
+		assert.Equal(t, ts.URL, health[0].URL)
+		assert.True(t, health[0].Active)
+		assert.Nil(t, health[0].GossipAge, "no check should be recorded yet")
+		assert.True(t, health[0].LastChecked.IsZero())
+	}
+
+	age = "2.5"
+	assert.True(t, sc.isNodeActive(node))
+	health = sc.ClusterHealth()
+	if assert.NotNil(t, health[0].GossipAge) {
+		assert.Equal(t, 2.5, *health[0].GossipAge)
+	}
+	assert.NoError(t, health[0].LastError)
+	assert.False(t, health[0].LastChecked.IsZero())
+
+	age = "20"
+	assert.False(t, sc.isNodeActive(node))
+	sc.DeactivateNodes(node)
+	health = sc.ClusterHealth()
+	assert.False(t, health[0].Active)
+	assert.Equal(t, 20.0, *health[0].GossipAge)
+	assert.Error(t, health[0].LastError, "the expired age should be reported")
+
+	age = ""
+	assert.False(t, sc.isNodeActive(node))
+	health = sc.ClusterHealth()
+	assert.Equal(t, 20.0, *health[0].GossipAge,
+		"the last observed gossip age should be kept")
+	assert.Contains(t, health[0].LastError.Error(), "gossip info")
+}
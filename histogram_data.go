@@ -2,22 +2,51 @@ package gosnowth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/circonus-labs/circonusllhist"
 	"github.com/pkg/errors"
 )
 
 // WriteHistogram - Write Histogram data to a node, data should be a slice of
-// Histogram Data and node is the node to write the data to
+// Histogram Data and node is the node to write the data to.  Data given as
+// bins is validated, and nothing is written if the bins of any data are not
+// in increasing order of value.
 func (sc *SnowthClient) WriteHistogram(node *SnowthNode, data ...HistogramData) (err error) {
+	return sc.WriteHistogramContext(context.Background(), node, data...)
+}
+
+// WriteHistogramContext - write histogram data to a node, as WriteHistogram
+// does, aborting the write when the context given is done
+func (sc *SnowthClient) WriteHistogramContext(ctx context.Context,
+	node *SnowthNode, data ...HistogramData) (err error) {
+	data = append([]HistogramData(nil), data...)
+	for i := range data {
+		data[i].Metric = sc.metricName(data[i].Metric)
+		if data[i].Bins == nil {
+			continue
+		}
+		h, err := histogramFromBins(data[i].Bins)
+		if err != nil {
+			return errors.Wrapf(err, "invalid histogram of %s",
+				data[i].Metric)
+		}
+		data[i].Histogram = h
+	}
 	buf := new(bytes.Buffer)
 	enc := json.NewEncoder(buf)
 	if err := enc.Encode(data); err != nil {
 		return errors.Wrap(err, "failed to encode HistogramData for write")
 	}
 
-	err = sc.do(node, "POST", "/histogram/write", buf, nil, nil)
+	err = sc.doContext(ctx, node, "POST", "/histogram/write", buf, nil, nil)
 	return
 }
 
@@ -28,4 +57,161 @@ type HistogramData struct {
 	Offset    int64                     `json:"offset"`
 	Period    int64                     `json:"period"`
 	Histogram *circonusllhist.Histogram `json:"histogram"`
+	// Bins, when set, are the bins of the histogram written, in place of
+	// the Histogram, in increasing order of value.
+	Bins []HistogramBin `json:"-"`
+}
+
+// histogramFromBins - create a histogram from its bins, which must be in
+// increasing order of value, with counts which are not negative
+func histogramFromBins(bins []HistogramBin) (*circonusllhist.Histogram, error) {
+	h := circonusllhist.New()
+	for i, bin := range bins {
+		if i > 0 && bin.Value <= bins[i-1].Value {
+			return nil, fmt.Errorf("bin values are not increasing: "+
+				"%g follows %g", bin.Value, bins[i-1].Value)
+		}
+		if bin.Count < 0 {
+			return nil, fmt.Errorf("negative count of bin %g", bin.Value)
+		}
+		if err := h.RecordValues(bin.Value, bin.Count); err != nil {
+			return nil, errors.Wrap(err, "failed to record histogram bin")
+		}
+	}
+	return h, nil
+}
+
+// ReadHistogramValues - Read histogram data from a node
+func (sc *SnowthClient) ReadHistogramValues(
+	node *SnowthNode, start, end time.Time, period int64,
+	id, metric string, opts ...ReadOption) ([]HistogramValue, error) {
+	return sc.ReadHistogramValuesContext(context.Background(), node, start,
+		end, period, id, metric, opts...)
+}
+
+// ReadHistogramValuesContext - read histogram data from a node, as
+// ReadHistogramValues does, aborting the read when the context given is done
+func (sc *SnowthClient) ReadHistogramValuesContext(ctx context.Context,
+	node *SnowthNode, start, end time.Time, period int64,
+	id, metric string, opts ...ReadOption) ([]HistogramValue, error) {
+
+	if err := checkPeriod(period); err != nil {
+		return nil, err
+	}
+	var (
+		ro = newReadOptions(opts)
+		r  []HistogramValue
+	)
+	err := sc.read(ctx, ro, MetricRef{ID: id, Metric: metric}, end, period,
+		func() (int, time.Time, error) {
+			r = []HistogramValue{}
+			err := sc.doContext(ctx, node, "GET",
+				path.Join("/histogram",
+					strconv.FormatInt(start.Unix(), 10),
+					strconv.FormatInt(end.Unix(), 10),
+					strconv.FormatInt(period, 10), id,
+					sc.metricName(metric)),
+				nil, &r, decodeJSONFromResponse)
+			if err != nil || len(r) == 0 {
+				return 0, time.Time{}, err
+			}
+			return len(r), r[len(r)-1].Time, nil
+		})
+	return r, err
+}
+
+// HistogramValue - a histogram read for a period, labelled with the time at
+// the start of the period
+type HistogramValue struct {
+	Time   time.Time
+	Period int64
+	Data   *circonusllhist.Histogram
+}
+
+// UnmarshalJSON - decode a histogram value from the tuple of time, period
+// and the counts of the histogram's bins keyed by the bin value
+func (hv *HistogramValue) UnmarshalJSON(b []byte) error {
+	var (
+		ts, period float64
+		bins       map[string]int64
+		tuple      = []interface{}{&ts, &period, &bins}
+	)
+	if err := json.Unmarshal(b, &tuple); err != nil {
+		return errors.Wrap(err, "failed to deserialize histogram value")
+	}
+	if len(tuple) < 3 {
+		return fmt.Errorf("histogram value should contain three entries, "+
+			"%d given in payload", len(tuple))
+	}
+	hv.Time = time.Unix(int64(ts), 0)
+	hv.Period = int64(period)
+	hv.Data = circonusllhist.New()
+	for bin, count := range bins {
+		v, err := strconv.ParseFloat(bin, 64)
+		if err != nil {
+			return errors.Wrap(err, "invalid histogram bin")
+		}
+		if err := hv.Data.RecordValues(v, count); err != nil {
+			return errors.Wrap(err, "failed to record histogram bin")
+		}
+	}
+	return nil
+}
+
+// HistogramBin - a bin of a histogram, by the value at the bottom of the
+// bin, and the number of samples counted in it
+type HistogramBin struct {
+	Value float64
+	Count int64
+}
+
+// Bins - the bins of the histogram with samples counted in them, in order of
+// their values
+func (hv *HistogramValue) Bins() ([]HistogramBin, error) {
+	return histogramBins(hv.Data)
+}
+
+// Quantiles - the approximate values of the histogram at the quantiles
+// given, each between 0 and 1
+func (hv *HistogramValue) Quantiles(qs ...float64) ([]float64, error) {
+	return hv.Data.ApproxQuantile(qs)
+}
+
+// histogramBins - the bins of a histogram with samples counted in them
+func histogramBins(h *circonusllhist.Histogram) ([]HistogramBin, error) {
+	bins := []HistogramBin{}
+	for _, s := range h.DecStrings() {
+		// bins are formatted as H[<value>]=<count>
+		parts := strings.SplitN(strings.TrimPrefix(s, "H["), "]=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid histogram bin: %s", s)
+		}
+		v, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid histogram bin value")
+		}
+		n, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid histogram bin count")
+		}
+		bins = append(bins, HistogramBin{Value: v, Count: n})
+	}
+	sort.Slice(bins, func(i, j int) bool {
+		return bins[i].Value < bins[j].Value
+	})
+	return bins, nil
+}
+
+// mergeHistogram - add the counts of the bins of one histogram to another
+func mergeHistogram(dst, src *circonusllhist.Histogram) error {
+	bins, err := histogramBins(src)
+	if err != nil {
+		return err
+	}
+	for _, bin := range bins {
+		if err := dst.RecordValues(bin.Value, bin.Count); err != nil {
+			return errors.Wrap(err, "failed to record histogram bin")
+		}
+	}
+	return nil
 }
@@ -0,0 +1,106 @@
+package gosnowth
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonusllhist"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadHistogramValues(t *testing.T) {
+	var response string
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/histogram/1380000000/1380000600/300/id/latency",
+			r.URL.Path)
+		w.Write([]byte(response))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	defer sc.Close()
+	read := func() ([]HistogramValue, error) {
+		return sc.ReadHistogramValues(node, time.Unix(1380000000, 0),
+			time.Unix(1380000600, 0), 300, "id", "latency")
+	}
+
+	response = `[[1380000000,300,{"10":2,"2.5":1}],[1380000300,300,{}]]`
+	values, err := read()
+	if err != nil {
+		t.Fatal("error reading histogram values: ", err)
+	}
+	if assert.Equal(t, 2, len(values)) {
+		assert.Equal(t, time.Unix(1380000000, 0), values[0].Time)
+		assert.Equal(t, int64(300), values[0].Period)
+		bins, err := values[0].Bins()
+		if err != nil {
+			t.Fatal("error getting bins: ", err)
+		}
+		assert.Equal(t, []HistogramBin{
+			{Value: 2.5, Count: 1},
+			{Value: 10, Count: 2},
+		}, bins, "bins should be ordered by value")
+		bins, err = values[1].Bins()
+		if err != nil {
+			t.Fatal("error getting bins: ", err)
+		}
+		assert.Equal(t, []HistogramBin{}, bins)
+	}
+
+	response = `[]`
+	values, err = read()
+	if err != nil {
+		t.Fatal("error reading empty response: ", err)
+	}
+	assert.Equal(t, 0, len(values))
+
+	response = `[[1380000000,300,{"ten":2}]]`
+	_, err = read()
+	assert.Error(t, err, "a malformed bin value should fail")
+
+	response = `[[1380000000,300,{"10":"two"}]]`
+	_, err = read()
+	assert.Error(t, err, "a malformed bin count should fail")
+}
+
+func TestWriteHistogramBins(t *testing.T) {
+	var written []map[string]interface{}
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		written = nil
+		json.NewDecoder(r.Body).Decode(&written)
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	defer sc.Close()
+
+	err := sc.WriteHistogram(node, HistogramData{
+		Metric: "latency",
+		ID:     "id",
+		Offset: 1380000000,
+		Period: 60,
+		Bins:   []HistogramBin{{Value: 1, Count: 2}, {Value: 10, Count: 1}},
+	})
+	if err != nil {
+		t.Fatal("error writing histogram: ", err)
+	}
+	if assert.Equal(t, 1, len(written)) {
+		h := circonusllhist.New()
+		h.RecordValues(1, 2)
+		h.RecordValues(10, 1)
+		b, _ := json.Marshal(h)
+		var expected string
+		json.Unmarshal(b, &expected)
+		assert.Equal(t, expected, written[0]["histogram"],
+			"bins should be written as a serialized histogram")
+	}
+
+	written = nil
+	err = sc.WriteHistogram(node, HistogramData{
+		Metric: "latency",
+		ID:     "id",
+		Bins:   []HistogramBin{{Value: 10, Count: 1}, {Value: 1, Count: 2}},
+	})
+	assert.Error(t, err, "bins out of order should be rejected")
+	assert.Nil(t, written, "nothing should be written for invalid bins")
+}
@@ -0,0 +1,90 @@
+package gosnowth
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LocateMetricAtTopology - find the nodes which owned a metric under the
+// topology with the hash given, which may be a past topology no longer in
+// use, for investigating data written while it was in effect.  The nodes
+// are in ring order, and are the client's own active nodes where it knows
+// them at the same address.  Other nodes have the addresses they had in that
+// topology, with the URL scheme and base path of the node given.  The
+// topology is fetched from the node given, which must still hold it.
+func (sc *SnowthClient) LocateMetricAtTopology(node *SnowthNode, hash string,
+	id, metric string) ([]*SnowthNode, error) {
+	return sc.LocateMetricAtTopologyContext(context.Background(), node,
+		hash, id, metric)
+}
+
+// LocateMetricAtTopologyContext - find the nodes which owned a metric under a
+// topology, as LocateMetricAtTopology does, aborting the requests when the
+// context given is done
+func (sc *SnowthClient) LocateMetricAtTopologyContext(ctx context.Context,
+	node *SnowthNode, hash string, id,
+	metric string) ([]*SnowthNode, error) {
+
+	ring, err := sc.fetchMetricRingByHash(ctx, node, hash)
+	if err != nil {
+		return nil, err
+	}
+	owners, err := ring.owners(id, sc.metricName(metric))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*SnowthNode, 0, len(owners))
+	for _, owner := range owners {
+		tn := ring.nodes[owner]
+		u := nodeURL(node.GetURL(), tn.Address, tn.APIPort)
+		if n := sc.findActiveNode(owner); n != nil &&
+			n.GetURL().Host == u.Host {
+			result = append(result, n)
+			continue
+		}
+		result = append(result, &SnowthNode{
+			identifier:      owner,
+			url:             u,
+			currentTopology: hash,
+		})
+	}
+	return result, nil
+}
+
+// ReadNNTValuesAtTopology - read NNT data as ReadNNTValues does, routing the
+// read to the owners of the metric under the topology with the hash given,
+// as found by LocateMetricAtTopology.  The owners are tried in ring order
+// until one of them responds.
+func (sc *SnowthClient) ReadNNTValuesAtTopology(node *SnowthNode,
+	hash string, start, end time.Time, period int64, t, id, metric string,
+	opts ...ReadOption) ([]NNTValue, error) {
+	return sc.ReadNNTValuesAtTopologyContext(context.Background(), node,
+		hash, start, end, period, t, id, metric, opts...)
+}
+
+// ReadNNTValuesAtTopologyContext - read NNT data from the owners of a metric
+// under a topology, as ReadNNTValuesAtTopology does, aborting the reads when
+// the context given is done
+func (sc *SnowthClient) ReadNNTValuesAtTopologyContext(ctx context.Context,
+	node *SnowthNode, hash string, start, end time.Time, period int64,
+	t, id, metric string, opts ...ReadOption) ([]NNTValue, error) {
+
+	owners, err := sc.LocateMetricAtTopologyContext(ctx, node, hash, id,
+		metric)
+	if err != nil {
+		return nil, err
+	}
+	mErr := newMultiError()
+	for _, owner := range owners {
+		values, err := sc.ReadNNTValuesContext(ctx, owner, start, end,
+			period, t, id, metric, opts...)
+		if err == nil {
+			return values, nil
+		}
+		mErr.Add(errors.Wrapf(err, "failed to read from %s",
+			owner.GetURL().Host))
+	}
+	return nil, mErr
+}
@@ -0,0 +1,55 @@
+package gosnowth
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadNNTValuesAtTopology(t *testing.T) {
+	const oldHash = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	var (
+		port     string
+		topoPath string
+	)
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/topology/xml/"):
+			topoPath = r.URL.Path
+			// under the old topology, the test node was bbbbbbbb
+			w.Write([]byte(strings.Replace(ringTopologyXMLTestData,
+				`address="10.8.20.2" port="8112" apiport="8112"`,
+				`address="127.0.0.1" port="8112" apiport="`+port+`"`, 1)))
+		case strings.HasPrefix(r.URL.Path, "/toporing/xml/"):
+			w.Write([]byte(ringTopoRingXMLTestData))
+		case strings.HasPrefix(r.URL.Path, "/read/"):
+			w.Write([]byte("[[1380000000,1]]"))
+		}
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	_, port, _ = net.SplitHostPort(node.GetURL().Host)
+
+	owners, err := sc.LocateMetricAtTopology(node, oldHash, ringTestUUID, "a")
+	if err != nil {
+		t.Fatal("error locating metric: ", err)
+	}
+	assert.Equal(t, "/topology/xml/"+oldHash, topoPath,
+		"the historical topology should be fetched")
+	if assert.Equal(t, 2, len(owners)) {
+		assert.Equal(t, "127.0.0.1:"+port, owners[0].GetURL().Host)
+		assert.Equal(t, "10.8.20.3:8112", owners[1].GetURL().Host)
+	}
+
+	data, err := sc.ReadNNTValuesAtTopology(node, oldHash,
+		time.Unix(1380000000, 0), time.Unix(1380000060, 0), 60, "count",
+		ringTestUUID, "a")
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, 1, len(data), "the read should route to the old owner")
+}
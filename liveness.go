@@ -0,0 +1,106 @@
+package gosnowth
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultGossipAgeThreshold - the gossip age, in seconds, beyond which a node
+// is not active by default
+const defaultGossipAgeThreshold = 10.0
+
+// WithGossipAgeThreshold - set the gossip age, in seconds, beyond which a
+// node is not considered active when watching for nodes becoming active or
+// inactive, for clusters which gossip more or less often than usual.  The
+// default is 10 seconds.
+func WithGossipAgeThreshold(seconds float64) ClientOption {
+	return func(sc *SnowthClient) error {
+		if seconds <= 0 {
+			return errors.New("gossip age threshold must be positive")
+		}
+		sc.gossipAgeThreshold = seconds
+		return nil
+	}
+}
+
+// LivenessCheck - a function reporting whether a node is alive, used by the
+// client in deciding whether a node is active
+type LivenessCheck func(node *SnowthNode) bool
+
+// WithLivenessCheck - check the liveness of nodes with the function given
+// when watching for nodes becoming active or inactive.  By default, the
+// check is made in addition to the gossip age check, and a node must pass
+// both to be active.  When replaceGossip is true, the gossip age check is
+// not made, for environments in which gossip is unreliable.
+func WithLivenessCheck(check LivenessCheck, replaceGossip bool) ClientOption {
+	return func(sc *SnowthClient) error {
+		sc.liveness = check
+		sc.livenessOnly = replaceGossip
+		return nil
+	}
+}
+
+// WithLivenessPath - check the liveness of nodes with a request to the path
+// given, such as a health endpoint, which must succeed for the node to be
+// alive.  The check replaces or adds to the gossip age check, as with
+// WithLivenessCheck.
+func WithLivenessPath(path string, replaceGossip bool) ClientOption {
+	return func(sc *SnowthClient) error {
+		return WithLivenessCheck(func(node *SnowthNode) bool {
+			if err := sc.do(node, "GET", path, nil, nil, nil); err != nil {
+				sc.Logger.Warnf("liveness check of node failed: %s",
+					err.Error())
+				return false
+			}
+			return true
+		}, replaceGossip)(sc)
+	}
+}
+
+// Ping - probe whether a node responds, with a HEAD request of its state,
+// returning nil if it responds with success within the timeout of the
+// client, for readiness checks.  Unlike the checks of the watch loop, the
+// gossip age of the node is not evaluated, and the node is neither
+// activated nor deactivated.
+func (sc *SnowthClient) Ping(node *SnowthNode) error {
+	return sc.PingContext(context.Background(), node)
+}
+
+// PingContext - probe whether a node responds, as Ping does, aborting the
+// request when the context given is done
+func (sc *SnowthClient) PingContext(ctx context.Context,
+	node *SnowthNode) error {
+	return sc.doContext(ctx, node, "HEAD", "/state", nil, nil, nil)
+}
+
+// WithWatchJitter - vary the interval between checks of the nodes of the
+// client randomly, by up to the fraction of the interval given, such as 0.2
+// for up to 20% either way, so that clients created together do not check
+// their nodes in lockstep.  With jitter, the first check is also made at a
+// random time within the first interval, rather than after a full interval.
+// By default, there is no jitter.
+func WithWatchJitter(fraction float64) ClientOption {
+	return func(sc *SnowthClient) error {
+		if fraction < 0 || fraction > 1 {
+			return errors.New("watch jitter must be between 0 and 1")
+		}
+		sc.watchJitter = fraction
+		return nil
+	}
+}
+
+// nextWatchInterval - the time until the next check of the nodes, varied by
+// the jitter of the client, if any
+func (sc *SnowthClient) nextWatchInterval(first bool) time.Duration {
+	if sc.watchJitter <= 0 {
+		return sc.watchInterval
+	}
+	if first {
+		return time.Duration(rand.Float64() * float64(sc.watchInterval))
+	}
+	spread := (rand.Float64()*2 - 1) * sc.watchJitter
+	return sc.watchInterval + time.Duration(spread*float64(sc.watchInterval))
+}
@@ -0,0 +1,134 @@
+package gosnowth
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLivenessCheck(t *testing.T) {
+	var healthy bool
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			if !healthy {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		case "/gossip/json":
+			// the node is current in gossip
+			w.Write([]byte(strings.Replace(gossipTestData,
+				"1f846f26-0cfd-4df5-b4f1-e0930604e577",
+				"bb6f7162-4828-11df-bab8-6bac200dcc2a", 1)))
+		}
+	})
+	defer ts.Close()
+
+	var alive bool
+	sc, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithLivenessCheck(func(*SnowthNode) bool { return alive }, false))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	node := sc.ListActiveNodes()[0]
+	assert.False(t, sc.isNodeActive(node),
+		"a node failing the liveness check should be inactive")
+	alive = true
+	assert.True(t, sc.isNodeActive(node),
+		"a node passing both checks should be active")
+
+	sc, err = NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithLivenessPath("/health", true))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	node = sc.ListActiveNodes()[0]
+	node.identifier = "unknown"
+	assert.False(t, sc.isNodeActive(node),
+		"an unhealthy node should be inactive")
+	healthy = true
+	assert.True(t, sc.isNodeActive(node),
+		"a healthy node should be active regardless of gossip")
+}
+
+func TestWithGossipAgeThreshold(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"bb6f7162-4828-11df-bab8-6bac200dcc2a",` +
+			`"gossip_age":"5.0"}]`))
+	})
+	defer ts.Close()
+
+	sc, node := newTestClient(t, ts)
+	assert.True(t, sc.isNodeActive(node),
+		"a moderate gossip age should be active by default")
+
+	sc, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithGossipAgeThreshold(2))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	assert.False(t, sc.isNodeActive(sc.ListActiveNodes()[0]),
+		"a moderate gossip age should be inactive under a low threshold")
+
+	_, err = NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithGossipAgeThreshold(0))
+	assert.Error(t, err, "the threshold should be positive")
+}
+
+func TestWithWatchJitter(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {})
+	defer ts.Close()
+	sc, _ := newTestClient(t, ts)
+	defer sc.Close()
+	assert.Equal(t, sc.watchInterval, sc.nextWatchInterval(true),
+		"there should be no jitter by default")
+
+	sc, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithWatchJitter(0.2))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+	var (
+		interval = sc.watchInterval
+		seen     = make(map[time.Duration]bool)
+	)
+	for i := 0; i < 100; i++ {
+		d := sc.nextWatchInterval(false)
+		assert.True(t, d >= interval*8/10 && d <= interval*12/10,
+			"interval %v should be within the jitter", d)
+		seen[d] = true
+		first := sc.nextWatchInterval(true)
+		assert.True(t, first >= 0 && first < interval,
+			"first interval %v should be within the interval", first)
+	}
+	assert.True(t, len(seen) > 1, "intervals should vary")
+
+	_, err = NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithWatchJitter(2))
+	assert.Error(t, err, "jitter beyond the interval should be rejected")
+}
+
+func TestPing(t *testing.T) {
+	var method string
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {})
+	ts.Config.Handler = func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method = r.Method
+			h.ServeHTTP(w, r)
+		})
+	}(ts.Config.Handler)
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	defer sc.Close()
+
+	assert.NoError(t, sc.Ping(node))
+	assert.Equal(t, "HEAD", method, "the probe should not fetch the state")
+
+	ts.Close()
+	assert.Error(t, sc.Ping(node), "an unreachable node should fail")
+	assert.Equal(t, []*SnowthNode{node}, sc.ListActiveNodes(),
+		"the probe should not deactivate the node")
+}
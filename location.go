@@ -1,13 +1,24 @@
 package gosnowth
 
 import (
+	"context"
 	"path"
 )
 
 // LocateMetric - locate which nodes a metric lives on
 func (sc *SnowthClient) LocateMetric(uuid string, metric string, node *SnowthNode) (location *DataLocation, err error) {
+	return sc.LocateMetricContext(context.Background(), uuid, metric, node)
+}
+
+// LocateMetricContext - locate which nodes a metric lives on, as LocateMetric
+// does, aborting the request when the context given is done
+func (sc *SnowthClient) LocateMetricContext(ctx context.Context,
+	uuid string, metric string,
+	node *SnowthNode) (location *DataLocation, err error) {
+
 	location = new(DataLocation)
-	err = sc.do(node, "GET", path.Join("/locate/xml", uuid, metric), nil, location, decodeXMLFromResponse)
+	err = sc.doContext(ctx, node, "GET", path.Join("/locate/xml", uuid,
+		sc.metricName(metric)), nil, location, decodeXMLFromResponse)
 	return
 }
 
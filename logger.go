@@ -0,0 +1,52 @@
+package gosnowth
+
+import (
+	"os"
+	"strings"
+
+	log "github.com/labstack/gommon/log"
+
+	"github.com/pkg/errors"
+)
+
+// Logger - the interface of the leveled logger the client writes its
+// diagnostics to, which may be implemented to route them into another
+// logging system.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// WithLogger - write the client's diagnostics to the logger given, in place
+// of the default logger, which writes to standard output at the level set by
+// the GOSNOWTH_LOGLEVEL environment variable, one of debug, info, warn or
+// error, logging nothing if it is not set.
+func WithLogger(logger Logger) ClientOption {
+	return func(sc *SnowthClient) error {
+		if logger == nil {
+			return errors.New("nil logger")
+		}
+		sc.Logger = logger
+		return nil
+	}
+}
+
+// newDefaultLogger - create the default logger of a client
+func newDefaultLogger() Logger {
+	logger := log.New("gosnowth")
+	switch strings.ToLower(os.Getenv("GOSNOWTH_LOGLEVEL")) {
+	case "debug":
+		logger.SetLevel(log.DEBUG)
+	case "info":
+		logger.SetLevel(log.INFO)
+	case "warn":
+		logger.SetLevel(log.WARN)
+	case "error":
+		logger.SetLevel(log.ERROR)
+	default:
+		logger.SetLevel(log.OFF)
+	}
+	return logger
+}
@@ -0,0 +1,86 @@
+package gosnowth
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	log "github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// testLogger - a logger recording the messages logged to it
+type testLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (tl *testLogger) log(level, format string, args ...interface{}) {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	tl.messages = append(tl.messages, level+": "+fmt.Sprintf(format, args...))
+}
+
+func (tl *testLogger) Debugf(format string, args ...interface{}) {
+	tl.log("debug", format, args...)
+}
+
+func (tl *testLogger) Infof(format string, args ...interface{}) {
+	tl.log("info", format, args...)
+}
+
+func (tl *testLogger) Warnf(format string, args ...interface{}) {
+	tl.log("warn", format, args...)
+}
+
+func (tl *testLogger) Errorf(format string, args ...interface{}) {
+	tl.log("error", format, args...)
+}
+
+func TestWithLogger(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {})
+	defer ts.Close()
+
+	logger := &testLogger{}
+	sc, err := NewSnowthClientWithOptions(false,
+		[]string{"http://127.0.0.1:1", ts.URL}, WithLogger(logger))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+
+	var bootstrapErrors int
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	for _, m := range logger.messages {
+		if strings.HasPrefix(m, "error: failed to bootstrap state of node") {
+			bootstrapErrors++
+		}
+	}
+	assert.Equal(t, 1, bootstrapErrors,
+		"bootstrap failures should be written to the logger given")
+
+	_, err = NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithLogger(nil))
+	assert.Error(t, err, "a nil logger should be rejected")
+}
+
+func TestDefaultLogger(t *testing.T) {
+	defer os.Setenv("GOSNOWTH_LOGLEVEL", os.Getenv("GOSNOWTH_LOGLEVEL"))
+	for level, expected := range map[string]log.Lvl{
+		"":      log.OFF,
+		"bogus": log.OFF,
+		"error": log.ERROR,
+		"WARN":  log.WARN,
+		"debug": log.DEBUG,
+	} {
+		os.Setenv("GOSNOWTH_LOGLEVEL", level)
+		logger, ok := newDefaultLogger().(*log.Logger)
+		if assert.True(t, ok, "the default logger should be a gommon logger") {
+			assert.Equal(t, expected, logger.Level(), "level %q", level)
+		}
+	}
+}
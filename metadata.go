@@ -0,0 +1,114 @@
+package gosnowth
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// MetricMetadata - the metadata of a metric, describing a series read from
+// it for rendering
+type MetricMetadata struct {
+	CheckName string
+	// Tags are the stream tags of the metric, as category:value pairs.
+	Tags []string
+	// Units is the value of the units tag of the metric, if it has one.
+	Units string
+}
+
+// NNTSeries - the NNT values read from a metric, with the metadata of the
+// metric when read with the WithMetadata option
+type NNTSeries struct {
+	ID       string
+	Metric   string
+	Values   []NNTValue
+	Metadata *MetricMetadata
+}
+
+// WithMetadata - join the metadata of each metric read by ReadNNTSeries with
+// its values, so that each series is self-describing.  The metadata is found
+// from the metrics of the check in the account given, which is fetched once
+// per check and cached with GetCheckMetadata.
+func WithMetadata(accountID int32) ReadOption {
+	return func(ro *readOptions) {
+		ro.metadata = true
+		ro.accountID = accountID
+	}
+}
+
+// ReadNNTSeries - read the NNT values of several metrics from a node, as
+// ReadNNTValues does for each, returning a series per metric in the order
+// given.
+func (sc *SnowthClient) ReadNNTSeries(node *SnowthNode, refs []MetricRef,
+	start, end time.Time, period int64, t string,
+	opts ...ReadOption) ([]NNTSeries, error) {
+	return sc.ReadNNTSeriesContext(context.Background(), node, refs, start,
+		end, period, t, opts...)
+}
+
+// ReadNNTSeriesContext - read NNT data with the metadata of its metric, as
+// ReadNNTSeries does, aborting the reads when the context given is done
+func (sc *SnowthClient) ReadNNTSeriesContext(ctx context.Context,
+	node *SnowthNode, refs []MetricRef, start, end time.Time, period int64,
+	t string, opts ...ReadOption) ([]NNTSeries, error) {
+
+	ro := newReadOptions(opts)
+	result := make([]NNTSeries, 0, len(refs))
+	for _, ref := range refs {
+		values, err := sc.ReadNNTValuesContext(ctx, node, start, end,
+			period, t, ref.ID, ref.Metric, opts...)
+		if err != nil {
+			return nil, err
+		}
+		s := NNTSeries{ID: ref.ID, Metric: ref.Metric, Values: values}
+		if ro.metadata {
+			md, err := sc.GetCheckMetadataContext(ctx, node,
+				ro.accountID, ref.ID)
+			if err != nil {
+				return nil, err
+			}
+			s.Metadata = metricMetadata(md, ref.Metric)
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+// metricMetadata - the metadata of a metric of a check.  A metric named
+// without its stream tags takes the tags of the first metric of the check
+// with the same base name.
+func metricMetadata(md *CheckMetadata, metric string) *MetricMetadata {
+	name := metric
+	if !strings.Contains(metric, "|ST[") {
+		for _, m := range md.Metrics {
+			if base, _ := parseMetricName(m); base == metric {
+				name = m
+				break
+			}
+		}
+	}
+	_, tags := parseMetricName(name)
+	mm := &MetricMetadata{CheckName: md.Name, Tags: tags}
+	for _, tag := range tags {
+		if strings.HasPrefix(tag, "units:") {
+			mm.Units = strings.TrimPrefix(tag, "units:")
+		}
+	}
+	return mm
+}
+
+// parseMetricName - split a metric name into its base name and its stream
+// tags
+func parseMetricName(metric string) (string, []string) {
+	i := strings.Index(metric, "|ST[")
+	if i < 0 || !strings.HasSuffix(metric, "]") {
+		return metric, nil
+	}
+	tags := []string{}
+	for _, tag := range strings.Split(metric[i+4:len(metric)-1], ",") {
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return metric[:i], tags
+}
@@ -0,0 +1,56 @@
+package gosnowth
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadNNTSeriesWithMetadata(t *testing.T) {
+	var finds int
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/find/"):
+			finds++
+			w.Write([]byte(`[{"uuid":"` + ringTestUUID + `",` +
+				`"check_name":"api","metric_name":` +
+				`"latency|ST[service:api,units:ms]"},` +
+				`{"uuid":"` + ringTestUUID + `",` +
+				`"check_name":"api","metric_name":"requests|ST[service:api]"}]`))
+		case strings.HasPrefix(r.URL.Path, "/read/"):
+			w.Write([]byte("[[1380000000,1]]"))
+		}
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	refs := []MetricRef{
+		{ID: ringTestUUID, Metric: "latency"},
+		{ID: ringTestUUID, Metric: "requests|ST[service:api]"},
+	}
+	series, err := sc.ReadNNTSeries(node, refs, time.Unix(1380000000, 0),
+		time.Unix(1380000300, 0), 300, "average", WithMetadata(1))
+	if err != nil {
+		t.Fatal("error reading series: ", err)
+	}
+	assert.Equal(t, 2, len(series))
+	assert.Equal(t, &MetricMetadata{
+		CheckName: "api",
+		Tags:      []string{"service:api", "units:ms"},
+		Units:     "ms",
+	}, series[0].Metadata, "tags of the metric should be joined")
+	assert.Equal(t, []string{"service:api"}, series[1].Metadata.Tags)
+	assert.Equal(t, 1, len(series[1].Values))
+	assert.Equal(t, 1, finds, "metadata should be fetched once per check")
+
+	series, err = sc.ReadNNTSeries(node, refs, time.Unix(1380000000, 0),
+		time.Unix(1380000300, 0), 300, "average")
+	if err != nil {
+		t.Fatal("error reading series: ", err)
+	}
+	assert.Nil(t, series[0].Metadata,
+		"metadata should only be joined when requested")
+}
@@ -2,7 +2,11 @@ package gosnowth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"math"
 	"path"
 	"strconv"
 	"time"
@@ -13,27 +17,72 @@ import (
 // WriteNNT - Write NNT data to a node, data should be a slice of NNTData
 // and node is the node to write the data to
 func (sc *SnowthClient) WriteNNT(node *SnowthNode, data ...NNTData) (err error) {
+	return sc.WriteNNTContext(context.Background(), node, data...)
+}
+
+// WriteNNTContext - write NNT data to a node, as WriteNNT does, aborting the
+// write when the context given is done
+func (sc *SnowthClient) WriteNNTContext(ctx context.Context, node *SnowthNode,
+	data ...NNTData) (err error) {
+	if sc.metricPrefix != "" {
+		data = append([]NNTData(nil), data...)
+		for i := range data {
+			data[i].Metric = sc.metricName(data[i].Metric)
+		}
+	}
 	buf := new(bytes.Buffer)
 	enc := json.NewEncoder(buf)
 	if err := enc.Encode(data); err != nil {
 		return errors.Wrap(err, "failed to encode NNTData for write")
 	}
-	err = sc.do(node, "POST", "/write/nnt", buf, nil, nil)
+	err = sc.doContext(ctx, node, "POST", "/write/nnt", buf, nil, nil)
 	return
 }
 
+// ReadNNTAllValues - read every aggregation of NNT data from a node, such
+// as the count, average and derivative, in a single request, rather than
+// one request per aggregation with ReadNNTValues
 func (sc *SnowthClient) ReadNNTAllValues(
 	node *SnowthNode, start, end time.Time, period int64,
-	id, metric string) ([]NNTAllValue, error) {
+	id, metric string, opts ...ReadOption) ([]NNTAllValue, error) {
+	return sc.ReadNNTAllValuesContext(context.Background(), node, start,
+		end, period, id, metric, opts...)
+}
+
+// ReadNNTAllValuesContext - read every aggregation of NNT data from a node,
+// as ReadNNTAllValues does, aborting the read when the context given is done
+func (sc *SnowthClient) ReadNNTAllValuesContext(ctx context.Context,
+	node *SnowthNode, start, end time.Time, period int64,
+	id, metric string, opts ...ReadOption) ([]NNTAllValue, error) {
 
+	if err := checkPeriod(period); err != nil {
+		return nil, err
+	}
 	var (
-		nntvr = new(NNTAllValueResponse)
-		err   = sc.do(node, "GET", path.Join("/read",
-			strconv.FormatInt(start.Unix(), 10),
-			strconv.FormatInt(end.Unix(), 10),
-			strconv.FormatInt(period, 10), id, "all", metric),
-			nil, nntvr, decodeJSONFromResponse)
+		ro    = newReadOptions(opts)
+		nntvr *NNTAllValueResponse
 	)
+	err := sc.read(ctx, ro, MetricRef{ID: id, Metric: metric}, end, period,
+		func() (int, time.Time, error) {
+			nntvr = &NNTAllValueResponse{Data: []NNTAllValue{}}
+			err := sc.doContext(ctx, node, "GET", path.Join("/read",
+				strconv.FormatInt(start.Unix(), 10),
+				strconv.FormatInt(end.Unix(), 10),
+				strconv.FormatInt(period, 10), id, "all",
+				sc.metricName(metric)),
+				nil, nntvr, decodeJSONFromResponse)
+			if err == nil && ro.checkWindow {
+				d := nntvr.Data
+				nntvr.Data = d[:sc.trimToWindow(metric,
+					periodStart(start, period), end, len(d),
+					func(i int) time.Time { return d[i].Time },
+					func(dst, src int) { d[dst] = d[src] })]
+			}
+			if err != nil || len(nntvr.Data) == 0 {
+				return 0, time.Time{}, err
+			}
+			return len(nntvr.Data), nntvr.Data[len(nntvr.Data)-1].Time, nil
+		})
 	return nntvr.Data, err
 }
 
@@ -51,6 +100,9 @@ func (nntvr *NNTAllValueResponse) UnmarshalJSON(b []byte) error {
 
 	for _, entry := range values {
 		var nntavr = NNTAllValue{}
+		if len(entry) < 2 {
+			return fmt.Errorf("invalid nnt all value tuple: %v", entry)
+		}
 		if m, ok := entry[1].(map[string]interface{}); ok {
 			valueBytes, err := json.Marshal(m)
 			if err != nil {
@@ -71,6 +123,9 @@ func (nntvr *NNTAllValueResponse) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// NNTAllValue - every aggregation of NNT data stored for a period, as read
+// in one request by ReadNNTAllValues.  Aggregations the node does not
+// report for the period are zero.
 type NNTAllValue struct {
 	Time              time.Time `json:"-"`
 	Count             int64     `json:"count"`
@@ -86,46 +141,254 @@ type NNTAllValue struct {
 	Counter2StdDev    int64     `json:"counter2_stddev"`
 }
 
-// ReadNNTValues - Read NNT data from a node
+// ReadNNTValues - Read NNT data from a node.  A window holding no data
+// returns no values, as an empty slice, without error, while a metric the
+// node does not know returns an error wrapping ErrMetricNotFound.  A period
+// which is not positive returns an error wrapping ErrInvalidPeriod without a
+// request being made.
 func (sc *SnowthClient) ReadNNTValues(
 	node *SnowthNode, start, end time.Time, period int64,
-	t, id, metric string) ([]NNTValue, error) {
+	t, id, metric string, opts ...ReadOption) ([]NNTValue, error) {
+	return sc.ReadNNTValuesContext(context.Background(), node, start, end,
+		period, t, id, metric, opts...)
+}
+
+// NNTAggregation - an aggregation of NNT data over a period, naming the
+// field of the data read by ReadNNTAggregation
+type NNTAggregation string
+
+// NNT aggregations, as named by snowth.
+const (
+	NNTCount            NNTAggregation = "count"
+	NNTAverage          NNTAggregation = "average"
+	NNTStddev           NNTAggregation = "stddev"
+	NNTDerivative       NNTAggregation = "derive"
+	NNTDerivativeStddev NNTAggregation = "derive_stddev"
+	NNTCounter          NNTAggregation = "counter"
+	NNTCounterStddev    NNTAggregation = "counter_stddev"
+)
+
+// Valid - is the aggregation one known to snowth
+func (a NNTAggregation) Valid() bool {
+	switch a {
+	case NNTCount, NNTAverage, NNTStddev, NNTDerivative, NNTDerivativeStddev,
+		NNTCounter, NNTCounterStddev:
+		return true
+	}
+	return false
+}
+
+// NNTAggregationValues - the values of a metric read with an aggregation,
+// with the aggregation and period they were read with, so that series read
+// with different periods may be aligned
+type NNTAggregationValues struct {
+	Aggregation NNTAggregation
+	Period      int64
+	Values      []NNTValue
+}
+
+// ReadNNTAggregation - read NNT data from a node, as ReadNNTValues does,
+// with a typed aggregation.  An unknown aggregation returns an error without
+// a request being made.
+func (sc *SnowthClient) ReadNNTAggregation(node *SnowthNode,
+	start, end time.Time, period int64, agg NNTAggregation, id, metric string,
+	opts ...ReadOption) (*NNTAggregationValues, error) {
+	return sc.ReadNNTAggregationContext(context.Background(), node, start,
+		end, period, agg, id, metric, opts...)
+}
+
+// ReadNNTAggregationContext - read an aggregation of NNT data, as
+// ReadNNTAggregation does, aborting the read when the context given is done
+func (sc *SnowthClient) ReadNNTAggregationContext(ctx context.Context,
+	node *SnowthNode, start, end time.Time, period int64,
+	agg NNTAggregation, id, metric string,
+	opts ...ReadOption) (*NNTAggregationValues, error) {
+
+	if !agg.Valid() {
+		return nil, fmt.Errorf("unknown nnt aggregation: %s", agg)
+	}
+	values, err := sc.ReadNNTValuesContext(ctx, node, start, end, period,
+		string(agg), id, metric, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &NNTAggregationValues{
+		Aggregation: agg,
+		Period:      period,
+		Values:      values,
+	}, nil
+}
+
+// ReadNNTValuesContext - read NNT data from a node, as ReadNNTValues does,
+// aborting the read when the context given is done
+func (sc *SnowthClient) ReadNNTValuesContext(ctx context.Context,
+	node *SnowthNode, start, end time.Time, period int64,
+	t, id, metric string, opts ...ReadOption) ([]NNTValue, error) {
 
+	if err := checkPeriod(period); err != nil {
+		return nil, err
+	}
 	var (
-		nntvr = new(NNTValueResponse)
-		err   = sc.do(node, "GET", path.Join("/read",
-			strconv.FormatInt(start.Unix(), 10),
-			strconv.FormatInt(end.Unix(), 10),
-			strconv.FormatInt(period, 10), id, t, metric),
-			nil, nntvr, decodeJSONFromResponse)
+		ro    = newReadOptions(opts)
+		nntvr *NNTValueResponse
 	)
-	return nntvr.Data, err
+	err := sc.read(ctx, ro, MetricRef{ID: id, Metric: metric}, end, period,
+		func() (int, time.Time, error) {
+			nntvr = &NNTValueResponse{Data: []NNTValue{},
+				numbers: ro.valueType == ValueTypeNumber}
+			err := sc.doContext(ctx, node, "GET", path.Join("/read",
+				strconv.FormatInt(start.Unix(), 10),
+				strconv.FormatInt(end.Unix(), 10),
+				strconv.FormatInt(period, 10), id, t, sc.metricName(metric)),
+				nil, nntvr, decodeJSONFromResponse)
+			if err == nil && ro.checkWindow {
+				d := nntvr.Data
+				nntvr.Data = d[:sc.trimToWindow(metric,
+					periodStart(start, period), end, len(d),
+					func(i int) time.Time { return d[i].Time },
+					func(dst, src int) { d[dst] = d[src] })]
+			}
+			if err != nil || len(nntvr.Data) == 0 {
+				return 0, time.Time{}, err
+			}
+			return len(nntvr.Data), nntvr.Data[len(nntvr.Data)-1].Time, nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return ro.processNNTValues(nntvr.Data, start, end, period), nil
+}
+
+// ErrStopIteration - returned by a callback given to ReadNNTValuesFunc or
+// ReadTextValuesFunc to stop reading values early, without the read
+// returning an error
+var ErrStopIteration = errors.New("stop iteration")
+
+// ReadNNTValuesFunc - read NNT data from a node, as ReadNNTValues does, but
+// call the function given with each value as it is decoded from the
+// response, rather than building a slice of the values.  Reading stops when
+// the function returns an error, which is returned by the read, unless it is
+// ErrStopIteration, which stops the read without error.
+func (sc *SnowthClient) ReadNNTValuesFunc(
+	node *SnowthNode, start, end time.Time, period int64,
+	t, id, metric string, fn func(NNTValue) error) error {
+	return sc.ReadNNTValuesFuncContext(context.Background(), node, start,
+		end, period, t, id, metric, fn)
+}
+
+// ReadNNTValuesFuncContext - read NNT data from a node, as ReadNNTValuesFunc
+// does, aborting the read when the context given is done
+func (sc *SnowthClient) ReadNNTValuesFuncContext(ctx context.Context,
+	node *SnowthNode, start, end time.Time, period int64,
+	t, id, metric string, fn func(NNTValue) error) error {
+
+	if err := checkPeriod(period); err != nil {
+		return err
+	}
+	decodeFunc := func(_ interface{}, reader io.Reader) error {
+		dec := json.NewDecoder(reader)
+		if _, err := dec.Token(); err != nil {
+			return errors.Wrap(err, "failed to decode nnt response")
+		}
+		for dec.More() {
+			var tuple []float64
+			if err := dec.Decode(&tuple); err != nil {
+				return errors.Wrap(err, "failed to decode nnt value")
+			}
+			if len(tuple) < 2 {
+				return fmt.Errorf("nnt value should contain two entries, "+
+					"%d given", len(tuple))
+			}
+			if err := fn(newNNTValue(time.Unix(int64(tuple[0]), 0),
+				tuple[1])); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err := sc.doContext(ctx, node, "GET", path.Join("/read",
+		strconv.FormatInt(start.Unix(), 10),
+		strconv.FormatInt(end.Unix(), 10),
+		strconv.FormatInt(period, 10), id, t, sc.metricName(metric)),
+		nil, fn, decodeFunc)
+	if errors.Cause(err) == ErrStopIteration {
+		return nil
+	}
+	return err
 }
 
 type NNTValueResponse struct {
 	Data []NNTValue
+	// numbers is whether the values should keep their numbers as returned.
+	numbers bool
 }
 
 func (nntvr *NNTValueResponse) UnmarshalJSON(b []byte) error {
 	nntvr.Data = []NNTValue{}
-	var values = [][]int64{}
+	var values = [][]json.Number{}
 
 	if err := json.Unmarshal(b, &values); err != nil {
 		return errors.Wrap(err, "failed to deserialize nnt average response")
 	}
 
 	for _, tuple := range values {
-		nntvr.Data = append(nntvr.Data, NNTValue{
-			Time:  time.Unix(tuple[0], 0),
-			Value: tuple[1],
-		})
+		if len(tuple) < 2 {
+			return fmt.Errorf("nnt value should contain two entries, "+
+				"%d given", len(tuple))
+		}
+		ts, _ := tuple[0].Float64()
+		v := NNTValue{Time: time.Unix(int64(ts), 0)}
+		if tuple[1] != "" {
+			f, err := tuple[1].Float64()
+			if err != nil {
+				return errors.Wrap(err, "failed to parse nnt value")
+			}
+			v.setFloat(f)
+			if i, err := tuple[1].Int64(); err == nil {
+				v.Value = i
+			}
+			if nntvr.numbers {
+				v.Number = tuple[1]
+			}
+		}
+		nntvr.Data = append(nntvr.Data, v)
 	}
 	return nil
 }
 
+// NNTValue - a value read for a bucket of a period's length, labelled with
+// the time at the start of the bucket
 type NNTValue struct {
-	Time  time.Time
+	Time time.Time
+	// Value is the value truncated to an integer.
 	Value int64
+	// Float is the value including any fraction, such as that of an average
+	// or of a value computed by the client.
+	Float float64
+	// End is the time at the end of the bucket, exclusive, which is only
+	// set when read with the WithBucketBounds option.
+	End time.Time
+	// Number is the value as returned by the node, preserving integers
+	// exactly, which is only set when read with the ValueTypeNumber option.
+	Number json.Number
+}
+
+// newNNTValue - create an NNT value with the value given, which may have a
+// fraction.
+func newNNTValue(t time.Time, f float64) NNTValue {
+	v := NNTValue{Time: t}
+	v.setFloat(f)
+	return v
+}
+
+// setFloat - set the value, including any fraction, and its integer part.
+func (v *NNTValue) setFloat(f float64) {
+	v.Float = f
+	v.Value = 0
+	if !math.IsNaN(f) && !math.IsInf(f, 0) {
+		v.Value = int64(f)
+	}
 }
 
 // ReadNNT - Read NNT data from a node
@@ -181,3 +444,51 @@ func (p *Parts) MarshalJSON() ([]byte, error) {
 	}
 	return buf.Bytes(), nil
 }
+
+// NNTDelta - the change in a metric's value across a window of time
+type NNTDelta struct {
+	Start NNTValue
+	End   NNTValue
+	Delta float64
+	// Rate is the change per second between the start and end values.
+	Rate float64
+}
+
+// ReadNNTDelta - read the change in a metric's value between the start and
+// end of a window of time, as read by ReadNNTValues.  When there are no
+// values at either end of the window, the values nearest to it within the
+// window are used instead, and the rate is computed over the time between
+// the values used.  ErrValueNotFound is returned if the window holds no
+// values at all.
+func (sc *SnowthClient) ReadNNTDelta(
+	node *SnowthNode, start, end time.Time, period int64,
+	t, id, metric string, opts ...ReadOption) (*NNTDelta, error) {
+	return sc.ReadNNTDeltaContext(context.Background(), node, start, end,
+		period, t, id, metric, opts...)
+}
+
+// ReadNNTDeltaContext - read the change in a metric across a window, as
+// ReadNNTDelta does, aborting the read when the context given is done
+func (sc *SnowthClient) ReadNNTDeltaContext(ctx context.Context,
+	node *SnowthNode, start, end time.Time, period int64,
+	t, id, metric string, opts ...ReadOption) (*NNTDelta, error) {
+
+	values, err := sc.ReadNNTValuesContext(ctx, node, start, end, period, t,
+		id, metric, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, errors.Wrapf(ErrValueNotFound,
+			"no values of %s in window", metric)
+	}
+	d := &NNTDelta{
+		Start: values[0],
+		End:   values[len(values)-1],
+	}
+	d.Delta = d.End.Float - d.Start.Float
+	if span := d.End.Time.Sub(d.Start.Time).Seconds(); span > 0 {
+		d.Rate = d.Delta / span
+	}
+	return d, nil
+}
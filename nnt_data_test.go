@@ -3,8 +3,13 @@ package gosnowth
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"path"
 	"testing"
 	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestNNTValue(t *testing.T) {
@@ -56,3 +61,157 @@ func TestNNTAllValue(t *testing.T) {
 		t.Error("error unmarshalling: ", err)
 	}
 }
+
+func TestReadNNTAllValues(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/read/1380000000/1380000120/60/id/all/metric",
+			r.URL.Path)
+		w.Write([]byte(`[
+			[1380000000,{"count":60,"value":10,"stddev":2,"derivative":1}],
+			[1380000060,{"count":30,"value":5}]
+		]`))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	values, err := sc.ReadNNTAllValues(node, time.Unix(1380000000, 0),
+		time.Unix(1380000120, 0), 60, "id", "metric")
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, []NNTAllValue{
+		{Time: time.Unix(1380000000, 0), Count: 60, Value: 10, StdDev: 2,
+			Derivitive: 1},
+		{Time: time.Unix(1380000060, 0), Count: 30, Value: 5},
+	}, values, "absent aggregations should be zero")
+
+	var nntavr NNTAllValueResponse
+	assert.Error(t, json.Unmarshal([]byte(`[[1380000000]]`), &nntavr),
+		"a tuple without values should be rejected")
+}
+
+func TestReadNNTDelta(t *testing.T) {
+	var data string
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(data))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	// the window end has no value, so the nearest earlier value is used
+	data = "[[1380000000,50],[1380000300,60],[1380000600,80]]"
+	d, err := sc.ReadNNTDelta(node, time.Unix(1380000000, 0),
+		time.Unix(1380000900, 0), 300, "average", "id", "metric")
+	if err != nil {
+		t.Fatal("error reading nnt delta: ", err)
+	}
+	assert.Equal(t, time.Unix(1380000600, 0), d.End.Time)
+	assert.Equal(t, float64(30), d.Delta)
+	assert.Equal(t, 0.05, d.Rate)
+
+	data = "[]"
+	_, err = sc.ReadNNTDelta(node, time.Unix(1380000000, 0),
+		time.Unix(1380000900, 0), 300, "average", "id", "metric")
+	assert.Equal(t, ErrValueNotFound, errors.Cause(err),
+		"an empty window should have no delta")
+}
+
+func TestReadNNTValuesFunc(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[[1380000000,1],[1380000300,2],[1380000600,3]]"))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	var values []NNTValue
+	err := sc.ReadNNTValuesFunc(node, time.Unix(1380000000, 0),
+		time.Unix(1380000600, 0), 300, "count", "id", "metric",
+		func(v NNTValue) error {
+			values = append(values, v)
+			if len(values) == 2 {
+				return ErrStopIteration
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, []NNTValue{
+		{Time: time.Unix(1380000000, 0), Value: 1, Float: 1},
+		{Time: time.Unix(1380000300, 0), Value: 2, Float: 2},
+	}, values, "iteration should stop early")
+
+	failure := errors.New("failure")
+	err = sc.ReadNNTValuesFunc(node, time.Unix(1380000000, 0),
+		time.Unix(1380000600, 0), 300, "count", "id", "metric",
+		func(v NNTValue) error {
+			return failure
+		})
+	assert.Equal(t, failure, errors.Cause(err),
+		"callback errors should be returned")
+}
+
+func TestReadNNTAggregation(t *testing.T) {
+	var requests int
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/read/1380000000/1380000060/60/id/derive/metric",
+			r.URL.Path)
+		w.Write([]byte("[[1380000000,1.5]]"))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	values, err := sc.ReadNNTAggregation(node, time.Unix(1380000000, 0),
+		time.Unix(1380000060, 0), 60, NNTDerivative, "id", "metric")
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, NNTDerivative, values.Aggregation)
+	assert.Equal(t, int64(60), values.Period)
+	assert.Equal(t, 1, len(values.Values))
+
+	_, err = sc.ReadNNTAggregation(node, time.Unix(1380000000, 0),
+		time.Unix(1380000060, 0), 60, NNTAggregation("mean"), "id", "metric")
+	assert.Error(t, err, "an unknown aggregation should be rejected")
+	assert.Equal(t, 1, requests, "no request should be made for it")
+}
+
+func TestReadNNTValuesNoData(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch path.Base(r.URL.Path) {
+		case "empty":
+			w.Write([]byte("[]"))
+		case "nocontent":
+			w.WriteHeader(http.StatusNoContent)
+		case "missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not found","message":"no metric"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	defer sc.Close()
+	start, end := time.Unix(1380000000, 0), time.Unix(1380000060, 0)
+
+	for _, metric := range []string{"empty", "nocontent"} {
+		values, err := sc.ReadNNTValues(node, start, end, 60, "count", "id",
+			metric)
+		assert.NoError(t, err, metric)
+		assert.Equal(t, []NNTValue{}, values,
+			"a window with no data should return an empty slice")
+	}
+
+	_, err := sc.ReadNNTValues(node, start, end, 60, "count", "id", "missing")
+	assert.True(t, errors.Is(err, ErrMetricNotFound),
+		"a missing metric should be reported as not found")
+	var se *SnowthError
+	assert.True(t, errors.As(err, &se), "the response should be kept")
+
+	_, err = sc.ReadNNTValues(node, start, end, 60, "count", "id", "broken")
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrMetricNotFound),
+		"other failures should not be reported as not found")
+}
@@ -0,0 +1,80 @@
+package gosnowth
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// NumericData - a plain numeric sample of a metric, for submission.  As
+// with TextData, the offset is the time of the sample in whole seconds since
+// the epoch, as a decimal string, which NewNumericData formats from a time.
+type NumericData struct {
+	Metric string  `json:"metric"`
+	ID     string  `json:"id"`
+	Offset string  `json:"offset"`
+	Value  float64 `json:"value"`
+}
+
+// NewNumericData - create numeric data for submission of a sample of a
+// metric at the time given, with the offset formatted in seconds since the
+// epoch, as NewTextData does.
+func NewNumericData(id, metric string, t time.Time,
+	value float64) NumericData {
+	return NumericData{
+		Metric: metric,
+		ID:     id,
+		Offset: formatOffset(t),
+		Value:  value,
+	}
+}
+
+// validate - check that numeric data may be written
+func (d NumericData) validate() error {
+	if err := validateMetricRef(d.ID, d.Metric); err != nil {
+		return err
+	}
+	if err := validateOffset(d.Offset); err != nil {
+		return err
+	}
+	if math.IsNaN(d.Value) || math.IsInf(d.Value, 0) {
+		return fmt.Errorf("invalid value: %v", d.Value)
+	}
+	return nil
+}
+
+// WriteNumeric - write numeric samples to a node, the node to write to is
+// given first, followed by any number of NumericData entries.  Every entry
+// is validated before anything is written, and an error naming the index of
+// the first invalid entry, such as one with a NaN or infinite value, is
+// returned if any is invalid.  The samples are stored as raw numeric data,
+// from which the node computes its rollups.
+func (sc *SnowthClient) WriteNumeric(node *SnowthNode,
+	data ...NumericData) error {
+	return sc.WriteNumericContext(context.Background(), node, data...)
+}
+
+// WriteNumericContext - write numeric data to a node, as WriteNumeric does,
+// aborting the write when the context given is done
+func (sc *SnowthClient) WriteNumericContext(ctx context.Context,
+	node *SnowthNode, data ...NumericData) error {
+
+	raw := make([]RawNumericData, 0, len(data))
+	for i, d := range data {
+		if err := d.validate(); err != nil {
+			return errors.Wrapf(err, "invalid numeric data at index %d", i)
+		}
+		offset, _ := strconv.ParseInt(d.Offset, 10, 64)
+		raw = append(raw, RawNumericData{
+			Metric: d.Metric,
+			ID:     d.ID,
+			Offset: offset * 1000,
+			Value:  d.Value,
+		})
+	}
+	return sc.WriteRawNumericContext(ctx, node, raw...)
+}
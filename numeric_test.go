@@ -0,0 +1,52 @@
+package gosnowth
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteNumeric(t *testing.T) {
+	var (
+		requests int
+		written  []RawNumericData
+	)
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/raw", r.URL.Path)
+		assert.Equal(t, "2", r.Header.Get("X-Snowth-Datapoints"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&written))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	defer sc.Close()
+
+	err := sc.WriteNumeric(node,
+		NewNumericData("id", "a", time.Unix(1380000000, 0), 1.5),
+		NumericData{Metric: "b", ID: "id", Offset: "1380000300", Value: -2})
+	assert.NoError(t, err)
+	assert.Equal(t, []RawNumericData{
+		{Metric: "a", ID: "id", Offset: 1380000000000, Value: 1.5},
+		{Metric: "b", ID: "id", Offset: 1380000300000, Value: -2},
+	}, written, "samples should be written with offsets in milliseconds")
+
+	for _, d := range []NumericData{
+		{Metric: "a", ID: "id", Offset: "soon", Value: 1},
+		{Metric: "a", ID: "id", Offset: "1380000000000", Value: 1},
+		{Metric: "a", ID: "id", Offset: "1380000000", Value: math.NaN()},
+		{Metric: "a", ID: "id", Offset: "1380000000", Value: math.Inf(1)},
+		{Metric: "", ID: "id", Offset: "1380000000", Value: 1},
+	} {
+		err := sc.WriteNumeric(node,
+			NumericData{Metric: "a", ID: "id", Offset: "1380000000"}, d)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "index 1")
+		}
+	}
+	assert.Equal(t, 1, requests, "invalid data should not be written")
+}
@@ -0,0 +1,49 @@
+package gosnowth
+
+import (
+	"time"
+)
+
+// RequestObservation - an observation of a request made by the client to a
+// node, for instrumentation
+type RequestObservation struct {
+	Method string
+	// Path is the path of the request, without its query.
+	Path string
+	// NodeID is the identifier of the node the request was made to.
+	NodeID string
+	// StatusCode is the status of the response, or zero if no response was
+	// received.
+	StatusCode int
+	// Duration is the time until the response was received or the request
+	// failed.
+	Duration time.Duration
+	// Err is the error which failed the request, if any.
+	Err error
+}
+
+// Observer - receives an observation of each request the client makes to a
+// node, including each retry, so that request counts, latencies and error
+// rates may be recorded with a metrics library of the caller's choice.
+// Observers must be safe for concurrent use.
+type Observer interface {
+	ObserveRequest(obs RequestObservation)
+}
+
+// noopObserver - the default observer, which ignores observations
+type noopObserver struct{}
+
+// ObserveRequest - ignore an observation
+func (noopObserver) ObserveRequest(RequestObservation) {}
+
+// WithObserver - pass an observation of each request the client makes to
+// the observer given.  By default, requests are not observed.
+func WithObserver(o Observer) ClientOption {
+	return func(sc *SnowthClient) error {
+		if o == nil {
+			o = noopObserver{}
+		}
+		sc.observer = o
+		return nil
+	}
+}
@@ -0,0 +1,66 @@
+package gosnowth
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingObserver - an observer which keeps its observations
+type recordingObserver struct {
+	mu  sync.Mutex
+	obs []RequestObservation
+}
+
+func (ro *recordingObserver) ObserveRequest(obs RequestObservation) {
+	ro.mu.Lock()
+	defer ro.mu.Unlock()
+	ro.obs = append(ro.obs, obs)
+}
+
+func TestWithObserver(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/write/text" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("[[1380000000,1]]"))
+	})
+	defer ts.Close()
+	ro := &recordingObserver{}
+	sc, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithObserver(ro))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+	node := sc.ListActiveNodes()[0]
+
+	_, err = sc.ReadNNTValues(node, time.Unix(1380000000, 0),
+		time.Unix(1380000060, 0), 60, "count", "id", "metric")
+	assert.NoError(t, err)
+	assert.Error(t, sc.WriteText(node, TextData{ID: "id", Metric: "m",
+		Offset: "1380000000"}))
+
+	ro.mu.Lock()
+	defer ro.mu.Unlock()
+	// the bootstrap state request is observed first
+	if assert.Equal(t, 3, len(ro.obs)) {
+		assert.Equal(t, "/state", ro.obs[0].Path)
+		read := ro.obs[1]
+		assert.Equal(t, "GET", read.Method)
+		assert.Equal(t, "/read/1380000000/1380000060/60/id/count/metric",
+			read.Path)
+		assert.Equal(t, node.identifier, read.NodeID)
+		assert.Equal(t, http.StatusOK, read.StatusCode)
+		assert.NoError(t, read.Err)
+		assert.True(t, read.Duration > 0)
+		write := ro.obs[2]
+		assert.Equal(t, "POST", write.Method)
+		assert.Equal(t, http.StatusBadRequest, write.StatusCode)
+		assert.Error(t, write.Err)
+	}
+}
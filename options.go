@@ -0,0 +1,6 @@
+package gosnowth
+
+// ClientOption - an option which configures a SnowthClient as it is
+// constructed with NewSnowthClientWithOptions.  An error returned by an
+// option aborts construction of the client.
+type ClientOption func(*SnowthClient) error
@@ -0,0 +1,81 @@
+package gosnowth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidPeriod - the cause of an error returned for a read with a period
+// which the nodes cannot read data with, returned before any request is made
+var ErrInvalidPeriod = errors.New("invalid period")
+
+// checkPeriod - return an error if a period is not a positive number of
+// seconds
+func checkPeriod(period int64) error {
+	if period <= 0 {
+		return errors.Wrapf(ErrInvalidPeriod,
+			"period should be positive, %d given", period)
+	}
+	return nil
+}
+
+// SupportsPeriod - whether data may be read with the period given, in
+// seconds, from the rollups.  Data is read from the largest rollup which
+// evenly divides the period, so the period must be a multiple of the
+// smallest rollup.  Any positive period is supported when no rollups are
+// known.
+func (r Rollup) SupportsPeriod(period int64) bool {
+	if period <= 0 {
+		return false
+	}
+	var smallest int64
+	for _, v := range r.RollupList {
+		if smallest == 0 || int64(v) < smallest {
+			smallest = int64(v)
+		}
+	}
+	return smallest == 0 || period%smallest == 0
+}
+
+// CheckNNTPeriod - check that a node supports reading NNT data with the
+// period given, in seconds, using the rollups reported in its state, for
+// tools which wish to give feedback on a period before reading.  An error
+// wrapping ErrInvalidPeriod, listing the rollups of the node, is returned if
+// the period is not supported.
+func (sc *SnowthClient) CheckNNTPeriod(node *SnowthNode, period int64) error {
+	return sc.CheckNNTPeriodContext(context.Background(), node, period)
+}
+
+// CheckNNTPeriodContext - check that a node supports reading with a period, as
+// CheckNNTPeriod does, aborting the request when the context given is done
+func (sc *SnowthClient) CheckNNTPeriodContext(ctx context.Context,
+	node *SnowthNode, period int64) error {
+
+	if err := checkPeriod(period); err != nil {
+		return err
+	}
+	state, err := sc.GetNodeStateContext(ctx, node)
+	if err != nil {
+		return errors.Wrap(err, "failed to get node state")
+	}
+	if !state.NNT.SupportsPeriod(period) {
+		return errors.Wrapf(ErrInvalidPeriod,
+			"period %d is not a multiple of a rollup of %s, rollups are: %s",
+			period, node.GetURL().Host, formatRollups(state.NNT.RollupList))
+	}
+	return nil
+}
+
+// formatRollups - the rollups given, as a list of periods
+func formatRollups(rollups []uint32) string {
+	s := ""
+	for i, v := range rollups {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprintf("%ds", v)
+	}
+	return s
+}
@@ -0,0 +1,57 @@
+package gosnowth
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadInvalidPeriod(t *testing.T) {
+	var requests int32
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte("[]"))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	defer sc.Close()
+
+	start, end := time.Unix(0, 0), time.Unix(60, 0)
+	_, err := sc.ReadNNTValues(node, start, end, 0, "count", "id", "metric")
+	assert.Equal(t, ErrInvalidPeriod, errors.Cause(err))
+	_, err = sc.ReadNNTAllValues(node, start, end, -60, "id", "metric")
+	assert.Equal(t, ErrInvalidPeriod, errors.Cause(err))
+	_, err = sc.ReadHistogramValues(node, start, end, 0, "id", "metric")
+	assert.Equal(t, ErrInvalidPeriod, errors.Cause(err))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&requests),
+		"no request should be made for an invalid period")
+
+	_, err = sc.ReadNNTValues(node, start, end, 60, "count", "id", "metric")
+	assert.NoError(t, err)
+}
+
+func TestRollupSupportsPeriod(t *testing.T) {
+	r := Rollup{RollupList: []uint32{600, 60, 7200}}
+	assert.True(t, r.SupportsPeriod(60))
+	assert.True(t, r.SupportsPeriod(300))
+	assert.False(t, r.SupportsPeriod(90))
+	assert.False(t, r.SupportsPeriod(0))
+	assert.True(t, Rollup{}.SupportsPeriod(1),
+		"any positive period should be supported with no rollups known")
+}
+
+func TestCheckNNTPeriod(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	defer sc.Close()
+
+	assert.NoError(t, sc.CheckNNTPeriod(node, 600))
+	err := sc.CheckNNTPeriod(node, 90)
+	assert.Equal(t, ErrInvalidPeriod, errors.Cause(err))
+	assert.Contains(t, err.Error(), "60s, 600s, 7200s, 86400s")
+}
@@ -0,0 +1,37 @@
+package gosnowth
+
+import (
+	"strings"
+)
+
+// WithMetricPrefix - set a namespace prefix for the metric names of the
+// client, for multi-tenant ingest.  The prefix is prepended to the names of
+// the metrics written, read and located by the client, and stripped from the
+// names of metrics found by tag queries, so that callers only ever see the
+// names without it.  Metrics found without the prefix belong to other
+// tenants, and are left out of the results.  The prefix is used as given,
+// and should include any separator desired.  An empty prefix, the default,
+// leaves names unchanged.
+// Data written with WriteRaw is sent as given, and must be prefixed by the
+// caller, while WriteRawNumeric prefixes the samples it encodes.
+func WithMetricPrefix(prefix string) ClientOption {
+	return func(sc *SnowthClient) error {
+		sc.metricPrefix = prefix
+		return nil
+	}
+}
+
+// metricName - the name of a metric as stored, with the client's prefix
+func (sc *SnowthClient) metricName(metric string) string {
+	return sc.metricPrefix + metric
+}
+
+// stripMetricPrefix - the name of a metric as stored, without the client's
+// prefix, and whether the name has the prefix, and so is a metric of the
+// client
+func (sc *SnowthClient) stripMetricPrefix(metric string) (string, bool) {
+	if !strings.HasPrefix(metric, sc.metricPrefix) {
+		return metric, false
+	}
+	return strings.TrimPrefix(metric, sc.metricPrefix), true
+}
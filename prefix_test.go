@@ -0,0 +1,84 @@
+package gosnowth
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMetricPrefix(t *testing.T) {
+	var written []map[string]interface{}
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/write/nnt":
+			b, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(b, &written)
+		case strings.HasPrefix(r.URL.Path, "/find/"):
+			// the metrics of other tenants are found as well
+			w.Write([]byte(`[{"uuid":"id","check_name":"check",` +
+				`"metric_name":"tenant.metric","category":"numeric",` +
+				`"type":"n","account_id":1},` +
+				`{"uuid":"id","check_name":"check",` +
+				`"metric_name":"other.metric","category":"numeric",` +
+				`"type":"n","account_id":1},` +
+				`{"uuid":"id","check_name":"check",` +
+				`"metric_name":"metric","category":"numeric",` +
+				`"type":"n","account_id":1}]`))
+		case r.URL.Path == "/read/1380000000/1380000300/300/id/count/tenant.metric":
+			w.Write([]byte("[[1380000000,1]]"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer ts.Close()
+	sc, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithMetricPrefix("tenant."))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	node := sc.ListActiveNodes()[0]
+
+	data := []NNTData{{Metric: "metric", ID: "id", Offset: 1380000000}}
+	if err := sc.WriteNNT(node, data...); err != nil {
+		t.Fatal("error writing nnt data: ", err)
+	}
+	assert.Equal(t, "tenant.metric", written[0]["metric"],
+		"the prefix should be applied on write")
+	assert.Equal(t, "metric", data[0].Metric,
+		"the data given should not be modified")
+
+	values, err := sc.ReadNNTValues(node, time.Unix(1380000000, 0),
+		time.Unix(1380000300, 0), 300, "count", "id", "metric")
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, 1, len(values), "the prefixed metric should be read")
+
+	items, err := sc.FindTags(node, 1, "and(__name:*)", "", "")
+	if err != nil {
+		t.Fatal("error finding tags: ", err)
+	}
+	if assert.Equal(t, 1, len(items),
+		"the metrics of other tenants should be left out") {
+		assert.Equal(t, "metric", items[0].MetricName,
+			"the prefix should be removed on read")
+	}
+	names, err := sc.FindMetrics(node, 1, "and(__name:*)", 0)
+	if err != nil {
+		t.Fatal("error finding metrics: ", err)
+	}
+	assert.Equal(t, []MetricName{{UUID: "id", Metric: "metric"}}, names,
+		"the metrics of other tenants should be left out")
+
+	// without a prefix, names are unchanged
+	sc, node = newTestClient(t, ts)
+	if err := sc.WriteNNT(node, data...); err != nil {
+		t.Fatal("error writing nnt data: ", err)
+	}
+	assert.Equal(t, "metric", written[0]["metric"])
+}
@@ -0,0 +1,89 @@
+package gosnowth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// rateLimiter - a token bucket limiting the rate at which requests are made.
+// Tokens accrue at the rate given, up to the burst size, and each request
+// takes one, waiting for it to accrue if the bucket is empty.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter - create a rate limiter allowing the number of requests per
+// second given, with bursts of up to the burst size, starting full
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve - take a token, returning how long to wait before it is available
+func (rl *rateLimiter) reserve() time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := time.Now()
+	rl.tokens += now.Sub(rl.last).Seconds() * rl.rate
+	if rl.tokens > rl.burst {
+		rl.tokens = rl.burst
+	}
+	rl.last = now
+	rl.tokens--
+	if rl.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-rl.tokens / rl.rate * float64(time.Second))
+}
+
+// cancel - return a token which was reserved but not used
+func (rl *rateLimiter) cancel() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.tokens++
+}
+
+// wait - wait until a request may be made, or the context is done
+func (rl *rateLimiter) wait(ctx context.Context) error {
+	delay := rl.reserve()
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		rl.cancel()
+		return ctx.Err()
+	}
+}
+
+// WithRateLimit - limit the rate of requests made by the client to all nodes
+// to the number of requests per second given, allowing bursts of up to the
+// burst size.  Requests over the limit wait for their turn, so that a runaway
+// caller cannot overwhelm the cluster.
+func WithRateLimit(rps float64, burst int) ClientOption {
+	return func(sc *SnowthClient) error {
+		if rps <= 0 {
+			return errors.Errorf("invalid rate limit: %v", rps)
+		}
+		sc.limiter = newRateLimiter(rps, burst)
+		return nil
+	}
+}
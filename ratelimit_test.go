@@ -0,0 +1,51 @@
+package gosnowth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRateLimit(t *testing.T) {
+	var reads int
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		reads++
+		w.Write([]byte("[]"))
+	})
+	defer ts.Close()
+	sc, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithRateLimit(50, 1))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	node := sc.ListActiveNodes()[0]
+
+	reads = 0
+	start := time.Now()
+	for i := 0; i < 11; i++ {
+		if _, err := sc.ReadNNTValues(node, time.Now(), time.Now(), 60,
+			"count", "id", "metric"); err != nil {
+			t.Fatal("error reading nnt values: ", err)
+		}
+	}
+	assert.Equal(t, 11, reads)
+	assert.True(t, time.Since(start) >= 190*time.Millisecond,
+		"requests should be limited to 50 per second")
+
+	_, err = NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithRateLimit(0, 1))
+	assert.Error(t, err, "a non-positive rate should fail")
+}
+
+func TestRateLimiterCancel(t *testing.T) {
+	rl := newRateLimiter(1, 1)
+	assert.NoError(t, rl.wait(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(),
+		10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, rl.wait(ctx),
+		"waiting should stop when the context is done")
+}
@@ -1,10 +1,14 @@
 package gosnowth
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
+	"net/url"
+	"path"
 	"strconv"
 	"time"
 
@@ -15,37 +19,209 @@ const (
 	FlatbufferContentType = "application/x-circonus-metric-list-flatbuffer"
 )
 
+// ErrValueNotFound - returned when no stored value satisfies a lookup
+var ErrValueNotFound = errors.New("no value found")
+
 // WriteRaw - Write Raw data to a node, data should be a io.Reader
 // and node is the node to write the data to
 func (sc *SnowthClient) WriteRaw(node *SnowthNode, data io.Reader, fb bool, dataPoints uint64) (err error) {
+	return sc.WriteRawContext(context.Background(), node, data, fb,
+		dataPoints)
+}
 
-	r, err := http.NewRequest("POST", sc.getURL(node, "/raw"), data)
-	if err != nil {
-		return errors.Wrap(err, "failed to create request")
-	}
-	r.Header.Add("X-Snowth-Datapoints", strconv.FormatUint(dataPoints, 10))
+// WriteRawContext - write raw data to a node, as WriteRaw does, aborting the
+// write when the context given is done
+func (sc *SnowthClient) WriteRawContext(ctx context.Context,
+	node *SnowthNode, data io.Reader, fb bool,
+	dataPoints uint64) (err error) {
+
+	header := http.Header{}
+	header.Set("X-Snowth-Datapoints", strconv.FormatUint(dataPoints, 10))
 	// is flatbuffer?
 	if fb {
-		r.Header.Add("Content-Type", FlatbufferContentType)
+		header.Set("Content-Type", FlatbufferContentType)
+	}
+
+	err = sc.doWithHeadersContext(ctx, node, "POST", "/raw", data, header,
+		nil, nil)
+	return
+}
+
+// WriteRawNumeric - write raw numeric samples to a node, encoded as json,
+// as WriteRaw does for data already encoded.
+func (sc *SnowthClient) WriteRawNumeric(node *SnowthNode,
+	data ...RawNumericData) error {
+	return sc.WriteRawNumericContext(context.Background(), node, data...)
+}
+
+// WriteRawNumericContext - write raw numeric samples to a node, as
+// WriteRawNumeric does, aborting the write when the context given is done
+func (sc *SnowthClient) WriteRawNumericContext(ctx context.Context,
+	node *SnowthNode, data ...RawNumericData) error {
+
+	data = append([]RawNumericData(nil), data...)
+	for i := range data {
+		data[i].Metric = sc.metricName(data[i].Metric)
+	}
+	buf := new(bytes.Buffer)
+	if err := json.NewEncoder(buf).Encode(data); err != nil {
+		return errors.Wrap(err, "failed to encode RawNumericData for write")
+	}
+	return sc.WriteRawContext(ctx, node, buf, false, uint64(len(data)))
+}
+
+// RawNumericData - a raw numeric sample of a metric, as stored by a node
+// before any rollup is applied.  The offset is the time of the sample in
+// milliseconds since the epoch.
+type RawNumericData struct {
+	Metric string  `json:"metric"`
+	ID     string  `json:"id"`
+	Offset int64   `json:"offset"`
+	Value  float64 `json:"value"`
+}
+
+// readRawNumeric - read the raw numeric samples of a metric from a node,
+// calling the function given with each sample as it is decoded from the
+// response, so that the full response is never held in memory.
+func (sc *SnowthClient) readRawNumeric(ctx context.Context,
+	node *SnowthNode, start, end time.Time, id, metric string,
+	fn func(RawNumericData) error) error {
+
+	decodeFunc := func(_ interface{}, reader io.Reader) error {
+		dec := json.NewDecoder(reader)
+		if _, err := dec.Token(); err != nil {
+			return errors.Wrap(err, "failed to decode raw response")
+		}
+		for dec.More() {
+			rnd, ok, err := decodeRawSample(dec, id, metric)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			if err := fn(rnd); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 
-	sc.Logger.Debugf("Snowth Request: %+v", r)
+	return sc.doContext(ctx, node, "GET", rawNumericPath(start, end, id,
+		sc.metricName(metric)), nil, fn,
+		decodeFunc)
+}
+
+// decodeRawSample - decode the next sample of a raw read of a metric, and
+// whether a value was recorded for it
+func decodeRawSample(dec *json.Decoder,
+	id, metric string) (RawNumericData, bool, error) {
+
+	var tuple []*float64
+	if err := dec.Decode(&tuple); err != nil {
+		return RawNumericData{}, false,
+			errors.Wrap(err, "failed to decode raw sample")
+	}
+	if len(tuple) < 2 || tuple[0] == nil {
+		return RawNumericData{}, false, fmt.Errorf(
+			"invalid raw sample, %d entries given", len(tuple))
+	}
+	if tuple[1] == nil {
+		// no value was recorded for this sample
+		return RawNumericData{}, false, nil
+	}
+	return RawNumericData{
+		Metric: metric,
+		ID:     id,
+		Offset: int64(*tuple[0]),
+		Value:  *tuple[1],
+	}, true, nil
+}
+
+// rawNumericPath - the path of a read of the raw numeric data of a metric
+func rawNumericPath(start, end time.Time, id, metric string) string {
+	return fmt.Sprintf("%s?start_ts=%d&end_ts=%d",
+		path.Join("/raw", id, url.QueryEscape(metric)),
+		start.Unix(), end.Unix())
+}
+
+// RawNumericValue - a raw numeric sample read from a node
+type RawNumericValue struct {
+	Time  time.Time
+	Value float64
+}
+
+// ReadRawNumericValues - read the raw numeric samples of a metric stored by
+// a node, at their original resolution rather than rolled up into periods,
+// in time order.  Sample times keep the millisecond offsets stored.
+func (sc *SnowthClient) ReadRawNumericValues(node *SnowthNode,
+	start, end time.Time, id, metric string) ([]RawNumericValue, error) {
+	return sc.ReadRawNumericValuesContext(context.Background(), node, start,
+		end, id, metric)
+}
 
-	var start = time.Now()
-	resp, err := sc.c.Do(r)
+// ReadRawNumericValuesContext - read the raw numeric samples of a metric, as
+// ReadRawNumericValues does, aborting the read when the context given is done
+func (sc *SnowthClient) ReadRawNumericValuesContext(ctx context.Context,
+	node *SnowthNode, start, end time.Time,
+	id, metric string) ([]RawNumericValue, error) {
+
+	values := []RawNumericValue{}
+	err := sc.readRawNumeric(ctx, node, start, end, id, metric,
+		func(rnd RawNumericData) error {
+			values = append(values, RawNumericValue{
+				Time:  time.Unix(0, rnd.Offset*int64(time.Millisecond)),
+				Value: rnd.Value,
+			})
+			return nil
+		})
 	if err != nil {
-		return errors.Wrap(err, "failed to perform request")
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return values, nil
+}
 
-	sc.Logger.Debugf("Snowth Response: %+v", resp)
-	sc.Logger.Debugf("Snowth Response Latency: %+v", time.Now().Sub(start))
+// GetNearestValue - get the single raw sample of a metric stored nearest to
+// the time given, rather than a value aggregated over a period.  Only
+// samples within the maximum distance of the time are considered, and
+// ErrValueNotFound is returned if there are none.  When two samples are
+// equally near, the earlier sample is returned.
+func (sc *SnowthClient) GetNearestValue(node *SnowthNode, id, metric string,
+	at time.Time, maxDistance time.Duration) (*RawNumericValue, error) {
+	return sc.GetNearestValueContext(context.Background(), node, id, metric,
+		at, maxDistance)
+}
+
+// GetNearestValueContext - find the stored value nearest to a time, as
+// GetNearestValue does, aborting the read when the context given is done
+func (sc *SnowthClient) GetNearestValueContext(ctx context.Context,
+	node *SnowthNode, id, metric string, at time.Time,
+	maxDistance time.Duration) (*RawNumericValue, error) {
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := ioutil.ReadAll(resp.Body)
-		sc.Logger.Warnf("status code not 200: %+v", resp)
-		return fmt.Errorf("non-success status code returned: %s -> %s",
-			resp.Status, string(body))
+	var (
+		nearest  *RawNumericValue
+		distance time.Duration
+	)
+	// the raw api window is in whole seconds, so round the end up
+	err := sc.readRawNumeric(ctx, node, at.Add(-maxDistance),
+		at.Add(maxDistance+time.Second), id, metric,
+		func(rnd RawNumericData) error {
+			t := time.Unix(0, rnd.Offset*int64(time.Millisecond))
+			d := t.Sub(at)
+			if d < 0 {
+				d = -d
+			}
+			if d <= maxDistance && (nearest == nil || d < distance) {
+				nearest = &RawNumericValue{Time: t, Value: rnd.Value}
+				distance = d
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
 	}
-	return
+	if nearest == nil {
+		return nil, ErrValueNotFound
+	}
+	return nearest, nil
 }
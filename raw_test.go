@@ -0,0 +1,74 @@
+package gosnowth
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNearestValue(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[[1380000000000,1],[1380000010000,2],
+			[1380000025000,3]]`))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	v, err := sc.GetNearestValue(node, "id", "metric",
+		time.Unix(1380000014, 0), time.Minute)
+	if err != nil {
+		t.Fatal("error getting nearest value: ", err)
+	}
+	assert.Equal(t, &RawNumericValue{
+		Time:  time.Unix(1380000010, 0),
+		Value: 2,
+	}, v, "should return the nearest sample")
+
+	_, err = sc.GetNearestValue(node, "id", "metric",
+		time.Unix(1380000040, 0), 5*time.Second)
+	assert.Equal(t, ErrValueNotFound, err,
+		"samples beyond the maximum distance should not be returned")
+}
+
+func TestReadRawNumericValues(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/raw/id/metric", r.URL.Path)
+		w.Write([]byte(`[[1380000000250,1.5],[1380000000750,null],
+			[1380000001125,2]]`))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	values, err := sc.ReadRawNumericValues(node, time.Unix(1380000000, 0),
+		time.Unix(1380000002, 0), "id", "metric")
+	if err != nil {
+		t.Fatal("error reading raw values: ", err)
+	}
+	assert.Equal(t, []RawNumericValue{
+		{Time: time.Unix(1380000000, 250*int64(time.Millisecond)), Value: 1.5},
+		{Time: time.Unix(1380000001, 125*int64(time.Millisecond)), Value: 2},
+	}, values, "sub-second offsets should be kept and nulls skipped")
+}
+
+func TestWriteRawNumeric(t *testing.T) {
+	var written []RawNumericData
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/raw", r.URL.Path)
+		assert.Equal(t, "2", r.Header.Get("X-Snowth-Datapoints"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&written))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	err := sc.WriteRawNumeric(node,
+		RawNumericData{ID: "id", Metric: "metric", Offset: 1380000000250,
+			Value: 1.5},
+		RawNumericData{ID: "id", Metric: "metric", Offset: 1380000001125,
+			Value: 2})
+	assert.NoError(t, err)
+	assert.Len(t, written, 2)
+	assert.Equal(t, int64(1380000000250), written[0].Offset)
+}
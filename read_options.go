@@ -0,0 +1,436 @@
+package gosnowth
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ingestLagWindow - how close to the present the end of a read window must
+// be for an empty result to be attributed to ingest lag.  This is one base
+// rollup period, the span in which freshly written data may not yet be
+// readable from a node.
+const ingestLagWindow = 60 * time.Second
+
+// defaultRetryBackoff - the initial backoff used between read retries when
+// a non-positive backoff is requested.
+const defaultRetryBackoff = 100 * time.Millisecond
+
+// ErrStaleData - returned when the latest value read is older than the
+// maximum staleness allowed by the read, or no value was read at all.
+var ErrStaleData = errors.New("data is staler than allowed")
+
+// ReadOption - an option which alters how a data read is performed, or how
+// the results of the read are processed before being returned.  Read options
+// may be passed to any of the data retrieval methods.
+type ReadOption func(*readOptions)
+
+// readOptions - the settings collected from the read options given to a
+// data retrieval method.
+type readOptions struct {
+	emptyRetry    bool
+	writeToken    *WriteToken
+	retryBackoff  time.Duration
+	retryDeadline time.Duration
+	interpolate   bool
+	maxGap        time.Duration
+	maxStaleness  time.Duration
+	smoothWindow  int
+	bucketBounds  bool
+	dense         bool
+	fill          float64
+	checkWindow   bool
+	round         bool
+	places        int
+	valueType     ValueType
+	metadata      bool
+	accountID     int32
+}
+
+// newReadOptions - apply the read options given to a new set of settings
+func newReadOptions(opts []ReadOption) *readOptions {
+	ro := new(readOptions)
+	for _, opt := range opts {
+		opt(ro)
+	}
+	return ro
+}
+
+// WithEmptyRetry - retry a read which returned no data, if the read window
+// includes very recent time.  Freshly written data may not be immediately
+// readable while a node ingests it, so the read is repeated with exponential
+// backoff, starting at the backoff duration given, until data is returned or
+// the deadline has passed since the first attempt.
+func WithEmptyRetry(backoff, deadline time.Duration) ReadOption {
+	return func(ro *readOptions) {
+		ro.emptyRetry = true
+		ro.retryBackoff = backoff
+		ro.retryDeadline = deadline
+	}
+}
+
+// WithWriteToken - wait for the writes recorded by a write token to be
+// readable, for read-your-writes consistency.  A read of a metric recorded
+// in the token is retried, in the manner of WithEmptyRetry, until it returns
+// a value at or after the latest offset written to the metric, or until the
+// deadline has passed since the first attempt.
+func WithWriteToken(token *WriteToken, deadline time.Duration) ReadOption {
+	return func(ro *readOptions) {
+		ro.writeToken = token
+		if deadline > ro.retryDeadline {
+			ro.retryDeadline = deadline
+		}
+	}
+}
+
+// WithInterpolation - fill gaps in NNT values with linearly interpolated
+// values.  The interpolation is performed client-side, as the read api has no
+// interpolation of its own, and fills gaps of missing periods spanning no
+// more than the maximum gap given.  Values are only interpolated between
+// values returned by the node, and never extrapolated past either end of the
+// series.
+func WithInterpolation(maxGap time.Duration) ReadOption {
+	return func(ro *readOptions) {
+		ro.interpolate = true
+		ro.maxGap = maxGap
+	}
+}
+
+// WithDenseGrid - return a dense series of NNT values, with a value for
+// every period of the read window, even when the node omits empty buckets
+// from its response.  Missing buckets, on the grid of periods aligned to the
+// epoch from the start of the window to its end, are filled with the value
+// given, after any interpolation has been applied.
+func WithDenseGrid(fill float64) ReadOption {
+	return func(ro *readOptions) {
+		ro.dense = true
+		ro.fill = fill
+	}
+}
+
+// WithWindowValidation - drop values returned by a node which fall outside
+// of the requested read window, logging a warning when any are dropped, to
+// catch misalignment between the client and the node.  For reads of rolled
+// up data, the window starts at the beginning of the period containing the
+// requested start time.
+func WithWindowValidation() ReadOption {
+	return func(ro *readOptions) {
+		ro.checkWindow = true
+	}
+}
+
+// WithRounding - round the values read to the number of decimal places
+// given, for consistent display.  Rounding applies to NNT and rollup values,
+// after any other processing, and never to timestamps or counts.
+func WithRounding(places int) ReadOption {
+	return func(ro *readOptions) {
+		ro.round = true
+		ro.places = places
+	}
+}
+
+// ValueType - the type of the values returned by a read of NNT values
+type ValueType int
+
+// The types values may be returned as.  Values read have a float64 Float by
+// default, whether the node returned an integer or a float, as values are
+// computed by different aggregations.  ValueTypeNumber also keeps each value
+// as the json.Number returned, so that integers too large to be represented
+// exactly by a float64 are preserved.
+const (
+	ValueTypeFloat64 ValueType = iota
+	ValueTypeNumber
+)
+
+// WithValueType - set the type of the NNT values read.  With
+// ValueTypeNumber, the Number of each value is set as well as its Value and
+// Float.
+// Values computed by the client, by interpolation, smoothing or rounding,
+// have their Number formatted from the computed value.
+func WithValueType(vt ValueType) ReadOption {
+	return func(ro *readOptions) {
+		ro.valueType = vt
+	}
+}
+
+// WithMovingAverage - smooth NNT values with a trailing moving average over
+// the number of values given.  The read api has no smoothing of its own, so
+// the average is computed client-side, after any interpolation.  Each value
+// is replaced by the average of itself and the values preceding it in the
+// window; at the start of the series, where fewer values precede it, the
+// average is taken over those available, so the series is not shortened.
+// A window of one or fewer values leaves the series unchanged.
+func WithMovingAverage(window int) ReadOption {
+	return func(ro *readOptions) {
+		ro.smoothWindow = window
+	}
+}
+
+// WithBucketBounds - set the end time of each NNT value's bucket, as well as
+// its start time, from the period of the read.  Each bucket ends where the
+// next period begins, so the buckets of consecutive values are contiguous.
+func WithBucketBounds() ReadOption {
+	return func(ro *readOptions) {
+		ro.bucketBounds = true
+	}
+}
+
+// WithMaxStaleness - require the latest value read to be no older than the
+// duration given, returning ErrStaleData otherwise.  This catches a node
+// which has fallen behind the rest of the cluster, so that the read may be
+// made from another node instead.  A read returning no values is considered
+// stale, as nothing was read to satisfy the requirement.
+func WithMaxStaleness(maxStaleness time.Duration) ReadOption {
+	return func(ro *readOptions) {
+		ro.maxStaleness = maxStaleness
+	}
+}
+
+// processNNTValues - apply the processing called for by the read options to
+// NNT values read from the window given, with the period given in seconds.
+func (ro *readOptions) processNNTValues(values []NNTValue,
+	start, end time.Time, period int64) []NNTValue {
+
+	if ro.interpolate && period > 0 {
+		values = interpolateNNTValues(values,
+			time.Duration(period)*time.Second, ro.maxGap)
+	}
+	if ro.dense && period > 0 {
+		values = fillNNTValues(values, start, end, period, ro.fill)
+	}
+	if ro.smoothWindow > 1 {
+		values = movingAverageNNTValues(values, ro.smoothWindow)
+	}
+	if ro.round {
+		for i := range values {
+			values[i].setFloat(ro.roundValue(values[i].Float))
+		}
+	}
+	if ro.bucketBounds && period > 0 {
+		for i := range values {
+			values[i].End = values[i].Time.Add(
+				time.Duration(period) * time.Second)
+		}
+	}
+	if ro.valueType == ValueTypeNumber {
+		for i := range values {
+			f, err := values[i].Number.Float64()
+			if err != nil || f != values[i].Float {
+				values[i].Number = json.Number(strconv.FormatFloat(
+					values[i].Float, 'f', -1, 64))
+			}
+		}
+	}
+	return values
+}
+
+// processRollupValues - apply the processing called for by the read options
+// to rollup values
+func (ro *readOptions) processRollupValues(values []RollupValues) []RollupValues {
+	if ro.round {
+		for i := range values {
+			values[i].Value = ro.roundValue(values[i].Value)
+		}
+	}
+	return values
+}
+
+// roundValue - round a value to the decimal places of the read options
+func (ro *readOptions) roundValue(v float64) float64 {
+	scale := math.Pow10(ro.places)
+	return math.Round(v*scale) / scale
+}
+
+// fillNNTValues - fill the buckets missing from values, on the grid of
+// periods covering the window, with the fill value
+func fillNNTValues(values []NNTValue, start, end time.Time, period int64,
+	fill float64) []NNTValue {
+
+	present := make(map[int64]NNTValue, len(values))
+	for _, v := range values {
+		present[v.Time.Unix()] = v
+	}
+	result := []NNTValue{}
+	for ts := start.Unix() - start.Unix()%period; ts <= end.Unix(); ts += period {
+		if v, ok := present[ts]; ok {
+			result = append(result, v)
+			delete(present, ts)
+			continue
+		}
+		result = append(result, newNNTValue(time.Unix(ts, 0), fill))
+	}
+	if len(present) > 0 {
+		// values off the grid are kept rather than silently dropped
+		for _, v := range present {
+			result = append(result, v)
+		}
+		sort.Slice(result, func(i, j int) bool {
+			return result[i].Time.Before(result[j].Time)
+		})
+	}
+	return result
+}
+
+// movingAverageNNTValues - replace each value with the trailing average of
+// up to window values ending with it
+func movingAverageNNTValues(values []NNTValue, window int) []NNTValue {
+	var (
+		result = make([]NNTValue, len(values))
+		sum    float64
+	)
+	for i, v := range values {
+		sum += v.Float
+		n := i + 1
+		if n > window {
+			sum -= values[i-window].Float
+			n = window
+		}
+		result[i] = newNNTValue(v.Time, sum/float64(n))
+	}
+	return result
+}
+
+// interpolateNNTValues - fill gaps of missing periods between values, no
+// longer than the maximum gap, with linearly interpolated values
+func interpolateNNTValues(values []NNTValue,
+	period, maxGap time.Duration) []NNTValue {
+
+	if len(values) < 2 {
+		return values
+	}
+	result := []NNTValue{values[0]}
+	for i := 1; i < len(values); i++ {
+		var (
+			prev = values[i-1]
+			next = values[i]
+			span = next.Time.Sub(prev.Time)
+		)
+		if span > period && span-period <= maxGap {
+			for t := prev.Time.Add(period); t.Before(next.Time); t = t.Add(period) {
+				frac := float64(t.Sub(prev.Time)) / float64(span)
+				f := prev.Float + (next.Float-prev.Float)*frac
+				result = append(result, newNNTValue(t, f))
+			}
+		}
+		result = append(result, next)
+	}
+	return result
+}
+
+// read - perform a read function for a metric, which returns the number of
+// values read and the time of the latest of them, according to the read
+// options.  When an empty retry was requested, reads of windows ending
+// within the ingest lag window are repeated while empty, and when a write
+// token was given, reads are repeated until the writes are observed.  The
+// period is that of the values read, in seconds, or 0 for reads of values
+// at the exact offsets written, such as text.  Retrying stops when the
+// context given is done.
+func (sc *SnowthClient) read(ctx context.Context, ro *readOptions,
+	ref MetricRef, end time.Time, period int64,
+	readFunc func() (int, time.Time, error)) error {
+
+	var (
+		deadline = time.Now().Add(ro.retryDeadline)
+		backoff  = ro.retryBackoff
+	)
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	for {
+		n, latest, err := readFunc()
+		if err != nil {
+			return err
+		}
+		if !ro.shouldRetry(ref, end, period, n, latest) {
+			return ro.checkStaleness(n, latest)
+		}
+		remaining := deadline.Sub(time.Now())
+		if remaining <= 0 {
+			return ro.checkStaleness(n, latest)
+		}
+		if backoff > remaining {
+			backoff = remaining
+		}
+		sc.Logger.Debugf("incomplete read, retrying in %v", backoff)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// outsideWindow - whether a time read falls outside of a read window
+func outsideWindow(t, start, end time.Time) bool {
+	return t.Before(start) || t.After(end)
+}
+
+// periodStart - the start of the period containing a time, for a period in
+// seconds
+func periodStart(t time.Time, period int64) time.Time {
+	if period <= 0 {
+		return t
+	}
+	return time.Unix(t.Unix()-t.Unix()%period, 0)
+}
+
+// trimToWindow - drop the values read of a metric which fall outside of the
+// read window, logging how many were dropped.  The n values read are
+// compacted in place, at giving the time of the value at an index and move
+// moving the value at one index to another, and the number kept is
+// returned, the kept values being those at the indexes below it.
+func (sc *SnowthClient) trimToWindow(metric string, start, end time.Time,
+	n int, at func(i int) time.Time, move func(dst, src int)) int {
+	kept := 0
+	for i := 0; i < n; i++ {
+		if outsideWindow(at(i), start, end) {
+			continue
+		}
+		move(kept, i)
+		kept++
+	}
+	if dropped := n - kept; dropped > 0 {
+		sc.Logger.Warnf("dropped %d values of %s outside of the read window",
+			dropped, metric)
+	}
+	return kept
+}
+
+// shouldRetry - whether a read which returned n values, the latest at the
+// time given, should be retried for the read options.  A write recorded in
+// the write token is only waited for if it falls within the read window, and
+// is observed once a value is read for the period containing it, as values
+// read with a period are labelled with the start of their period.
+func (ro *readOptions) shouldRetry(ref MetricRef, end time.Time,
+	period int64, n int, latest time.Time) bool {
+	if ro.writeToken != nil {
+		if written, ok := ro.writeToken.offset(ref); ok &&
+			!written.After(end) &&
+			(n == 0 || latest.Before(periodStart(written, period))) {
+			return true
+		}
+	}
+	return n == 0 && ro.emptyRetry &&
+		!end.Before(time.Now().Add(-ingestLagWindow))
+}
+
+// checkStaleness - check the latest of the values read against the maximum
+// staleness allowed
+func (ro *readOptions) checkStaleness(n int, latest time.Time) error {
+	if ro.maxStaleness <= 0 {
+		return nil
+	}
+	if n == 0 {
+		return errors.Wrap(ErrStaleData, "no values read")
+	}
+	if age := time.Now().Sub(latest); age > ro.maxStaleness {
+		return errors.Wrapf(ErrStaleData, "latest value is %v old", age)
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package gosnowth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithEmptyRetry(t *testing.T) {
+	var reads int
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		reads++
+		if reads == 1 {
+			w.Write([]byte("[]"))
+			return
+		}
+		fmt.Fprintf(w, "[[%d,1]]", time.Now().Unix())
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	data, err := sc.ReadNNTValues(node, time.Now().Add(-time.Minute),
+		time.Now(), 60, "count", "id", "metric",
+		WithEmptyRetry(time.Millisecond, time.Second))
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, 2, reads, "empty read should be retried")
+	assert.Equal(t, 1, len(data), "retried read should return data")
+
+	// windows outside of the ingest lag are not retried
+	reads = 0
+	data, err = sc.ReadNNTValues(node, time.Now().Add(-time.Hour),
+		time.Now().Add(-30*time.Minute), 60, "count", "id", "metric",
+		WithEmptyRetry(time.Millisecond, time.Second))
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, 1, reads, "old window should not be retried")
+	assert.Equal(t, 0, len(data), "old window should be empty")
+}
+
+func TestWithInterpolation(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[[1380000000,10],[1380000120,20],[1380000600,30]]"))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	data, err := sc.ReadNNTValues(node, time.Unix(1380000000, 0),
+		time.Unix(1380000600, 0), 60, "average", "id", "metric",
+		WithInterpolation(2*time.Minute))
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, []NNTValue{
+		{Time: time.Unix(1380000000, 0), Value: 10, Float: 10},
+		{Time: time.Unix(1380000060, 0), Value: 15, Float: 15},
+		{Time: time.Unix(1380000120, 0), Value: 20, Float: 20},
+		{Time: time.Unix(1380000600, 0), Value: 30, Float: 30},
+	}, data, "only the single period gap should be filled")
+}
+
+func TestWithMaxStaleness(t *testing.T) {
+	var latest time.Time
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "[[%d,1]]", latest.Unix())
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	latest = time.Now().Add(-10 * time.Minute)
+	_, err := sc.ReadNNTValues(node, time.Now().Add(-time.Hour), time.Now(),
+		60, "count", "id", "metric", WithMaxStaleness(5*time.Minute))
+	assert.Equal(t, ErrStaleData, errors.Cause(err),
+		"read from a stale node should be rejected")
+
+	latest = time.Now().Add(-time.Minute)
+	data, err := sc.ReadNNTValues(node, time.Now().Add(-time.Hour),
+		time.Now(), 60, "count", "id", "metric",
+		WithMaxStaleness(5*time.Minute))
+	if err != nil {
+		t.Fatal("error reading fresh data: ", err)
+	}
+	assert.Equal(t, 1, len(data), "fresh data should be returned")
+}
+
+func TestWithMovingAverage(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[[1380000000,3],[1380000060,6],[1380000120,9]," +
+			"[1380000180,3]]"))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	data, err := sc.ReadNNTValues(node, time.Unix(1380000000, 0),
+		time.Unix(1380000180, 0), 60, "average", "id", "metric",
+		WithMovingAverage(3))
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, []NNTValue{
+		{Time: time.Unix(1380000000, 0), Value: 3, Float: 3},
+		{Time: time.Unix(1380000060, 0), Value: 4, Float: 4.5},
+		{Time: time.Unix(1380000120, 0), Value: 6, Float: 6},
+		{Time: time.Unix(1380000180, 0), Value: 6, Float: 6},
+	}, data, "values should be averaged over the trailing window")
+}
+
+func TestWithBucketBounds(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[[1380000000,1],[1380000300,2],[1380000600,3]]"))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	data, err := sc.ReadNNTValues(node, time.Unix(1380000000, 0),
+		time.Unix(1380000600, 0), 300, "count", "id", "metric",
+		WithBucketBounds())
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, 3, len(data))
+	for i, v := range data {
+		assert.Equal(t, 300*time.Second, v.End.Sub(v.Time),
+			"buckets should span the period")
+		if i > 0 {
+			assert.Equal(t, data[i-1].End, v.Time,
+				"buckets should be contiguous")
+		}
+	}
+}
+
+func TestWithDenseGrid(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		// empty buckets are omitted by the node
+		w.Write([]byte("[[1380000060,1],[1380000240,4]]"))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	data, err := sc.ReadNNTValues(node, time.Unix(1380000000, 0),
+		time.Unix(1380000300, 0), 60, "count", "id", "metric",
+		WithDenseGrid(0))
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, []NNTValue{
+		{Time: time.Unix(1380000000, 0), Value: 0, Float: 0},
+		{Time: time.Unix(1380000060, 0), Value: 1, Float: 1},
+		{Time: time.Unix(1380000120, 0), Value: 0, Float: 0},
+		{Time: time.Unix(1380000180, 0), Value: 0, Float: 0},
+		{Time: time.Unix(1380000240, 0), Value: 4, Float: 4},
+		{Time: time.Unix(1380000300, 0), Value: 0, Float: 0},
+	}, data, "every period of the window should have a value")
+
+	ts.Close()
+	data, err = sc.ReadNNTValues(node, time.Unix(1380000000, 0),
+		time.Unix(1380000300, 0), 60, "count", "id", "metric",
+		WithDenseGrid(0))
+	assert.Error(t, err)
+	assert.Nil(t, data, "failed reads should not be filled")
+}
+
+func TestWithWindowValidation(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[[1379999940,9],[1380000000,1],[1380000060,2]," +
+			"[1380000120,9]]"))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	data, err := sc.ReadNNTValues(node, time.Unix(1380000030, 0),
+		time.Unix(1380000060, 0), 60, "count", "id", "metric",
+		WithWindowValidation())
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, []NNTValue{
+		{Time: time.Unix(1380000000, 0), Value: 1, Float: 1},
+		{Time: time.Unix(1380000060, 0), Value: 2, Float: 2},
+	}, data, "values outside of the window should be trimmed")
+
+	data, err = sc.ReadNNTValues(node, time.Unix(1380000030, 0),
+		time.Unix(1380000060, 0), 60, "count", "id", "metric")
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, 4, len(data), "values should only be trimmed on request")
+}
+
+func TestWithRounding(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/rollup/"):
+			w.Write([]byte("[[1380000000,2.71828]]"))
+		default:
+			w.Write([]byte("[[1380000000,3.14159],[1380000060,-1.005]]"))
+		}
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	data, err := sc.ReadNNTValues(node, time.Unix(1380000000, 0),
+		time.Unix(1380000060, 0), 60, "average", "id", "metric",
+		WithRounding(2))
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, []NNTValue{
+		{Time: time.Unix(1380000000, 0), Value: 3, Float: 3.14},
+		{Time: time.Unix(1380000060, 0), Value: -1, Float: -1},
+	}, data, "values should be rounded to two places")
+
+	rollup, err := sc.ReadRollupValues(node, "id", "metric", nil,
+		time.Minute, time.Unix(1380000000, 0), time.Unix(1380000060, 0),
+		WithRounding(1))
+	if err != nil {
+		t.Fatal("error reading rollup values: ", err)
+	}
+	assert.Equal(t, []RollupValues{
+		{Timestamp: 1380000000, Value: 2.7},
+	}, rollup, "rollup values should be rounded to one place")
+}
+
+func TestWithValueType(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[[1380000000,2],[1380000300,2.5]," +
+			"[1380000600,9007199254740993]]"))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	data, err := sc.ReadNNTValues(node, time.Unix(1380000000, 0),
+		time.Unix(1380000600, 0), 300, "count", "id", "metric")
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, []NNTValue{
+		{Time: time.Unix(1380000000, 0), Value: 2, Float: 2},
+		{Time: time.Unix(1380000300, 0), Value: 2, Float: 2.5},
+		{Time: time.Unix(1380000600, 0), Value: 9007199254740993,
+			Float: 9007199254740992},
+	}, data, "floats should be coerced to float64 by default")
+
+	data, err = sc.ReadNNTValues(node, time.Unix(1380000000, 0),
+		time.Unix(1380000600, 0), 300, "count", "id", "metric",
+		WithValueType(ValueTypeNumber))
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, []json.Number{"2", "2.5", "9007199254740993"},
+		[]json.Number{data[0].Number, data[1].Number, data[2].Number},
+		"numbers should be preserved as returned")
+	assert.Equal(t, 2.5, data[1].Float)
+
+	data, err = sc.ReadNNTValues(node, time.Unix(1380000000, 0),
+		time.Unix(1380000600, 0), 300, "count", "id", "metric",
+		WithValueType(ValueTypeNumber), WithMovingAverage(2))
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, json.Number("2.25"), data[1].Number,
+		"computed values should have their numbers formatted")
+}
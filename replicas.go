@@ -0,0 +1,166 @@
+package gosnowth
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Replica combine modes, which determine how the values read from several
+// replicas of a metric are combined into a single result.
+const (
+	// ReplicaCombineFirst - use the values of the first replica to respond
+	// successfully.
+	ReplicaCombineFirst = "first"
+	// ReplicaCombineMerge - use the union of the values of all replicas,
+	// preferring the values of replicas earlier in the ring where they
+	// disagree.
+	ReplicaCombineMerge = "merge"
+	// ReplicaCombineMajority - use only the values on which a majority of
+	// the replicas queried agree.
+	ReplicaCombineMajority = "majority"
+)
+
+// replicaResult - the values read from a replica, by its position in the
+// list of replicas queried
+type replicaResult struct {
+	index  int
+	values []NNTValue
+	err    error
+}
+
+// ReadNNTValuesReplicas - read NNT data from the number of replicas of the
+// metric given, combining the results as the combine mode directs.  The
+// replicas are the owners of the metric according to the topology ring in
+// use by the node given, in ring order, or all active nodes, in the fallback
+// node order, if the node knows no topology.  Replicas are queried
+// concurrently.  An error is returned if there are fewer active owners than
+// replicas asked for, or if too few replicas respond to satisfy the combine
+// mode.
+func (sc *SnowthClient) ReadNNTValuesReplicas(node *SnowthNode, replicas int,
+	combine string, start, end time.Time, period int64, t, id, metric string,
+	opts ...ReadOption) ([]NNTValue, error) {
+	return sc.ReadNNTValuesReplicasContext(context.Background(), node,
+		replicas, combine, start, end, period, t, id, metric, opts...)
+}
+
+// ReadNNTValuesReplicasContext - read NNT data from the replicas of a metric,
+// as ReadNNTValuesReplicas does, aborting the reads when the context given is
+// done
+func (sc *SnowthClient) ReadNNTValuesReplicasContext(ctx context.Context,
+	node *SnowthNode, replicas int, combine string, start, end time.Time,
+	period int64, t, id, metric string,
+	opts ...ReadOption) ([]NNTValue, error) {
+
+	switch combine {
+	case ReplicaCombineFirst, ReplicaCombineMerge, ReplicaCombineMajority:
+	default:
+		return nil, fmt.Errorf("unknown replica combine mode: %s", combine)
+	}
+	ring, err := sc.routingRing(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+	owners, err := ring.owners(id, sc.metricName(metric))
+	if err != nil {
+		return nil, err
+	}
+	var nodes []*SnowthNode
+	for _, owner := range owners {
+		if n := sc.findActiveNode(owner); n != nil && len(nodes) < replicas {
+			nodes = append(nodes, n)
+		}
+	}
+	if replicas <= 0 || len(nodes) < replicas {
+		return nil, fmt.Errorf("%d replicas requested, %d active owners of %s",
+			replicas, len(nodes), metric)
+	}
+
+	results := make(chan replicaResult, len(nodes))
+	for i, n := range nodes {
+		go func(i int, n *SnowthNode) {
+			values, err := sc.ReadNNTValuesContext(ctx, n, start,
+				end, period, t, id, metric, opts...)
+			results <- replicaResult{index: i, values: values, err: err}
+		}(i, n)
+	}
+
+	var (
+		mErr    = newMultiError()
+		success = make([][]NNTValue, len(nodes))
+		count   int
+	)
+	for range nodes {
+		r := <-results
+		if r.err != nil {
+			mErr.Add(errors.Wrapf(r.err, "failed to read from replica %s",
+				nodes[r.index].GetURL().Host))
+			continue
+		}
+		if combine == ReplicaCombineFirst {
+			return r.values, nil
+		}
+		success[r.index] = r.values
+		count++
+	}
+
+	switch combine {
+	case ReplicaCombineMerge:
+		if count == 0 {
+			return nil, mErr
+		}
+		return mergeReplicaValues(success, 1), nil
+	case ReplicaCombineMajority:
+		need := len(nodes)/2 + 1
+		if count < need {
+			mErr.Add(fmt.Errorf("%d of %d replicas responded, %d needed",
+				count, len(nodes), need))
+			return nil, mErr
+		}
+		return mergeReplicaValues(success, need), nil
+	}
+	return nil, mErr
+}
+
+// mergeReplicaValues - combine the values of replicas, keeping a value at
+// each time at which at least the number of replicas given agree on it.
+// Where more than one value qualifies, the value of the earliest replica is
+// kept.  The result is in time order.
+func mergeReplicaValues(replicas [][]NNTValue, need int) []NNTValue {
+	var (
+		times  []time.Time
+		byTime = make(map[time.Time][]NNTValue)
+	)
+	for _, values := range replicas {
+		for _, v := range values {
+			if _, ok := byTime[v.Time]; !ok {
+				times = append(times, v.Time)
+			}
+			byTime[v.Time] = append(byTime[v.Time], v)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+
+	result := []NNTValue{}
+	for _, ts := range times {
+		candidates := byTime[ts]
+		for _, c := range candidates {
+			agree := 0
+			for _, o := range candidates {
+				if o.Float == c.Float {
+					agree++
+				}
+			}
+			if agree >= need {
+				result = append(result, c)
+				break
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,77 @@
+package gosnowth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newReplicaTestServer - create a test node with the identity given, which
+// serves the ring test topology, keeping three copies of each metric, and
+// answers reads with the data given
+func newReplicaTestServer(identity, data string, reads *int32) *httptest.Server {
+	return newRingNodeTestServer(identity, 3,
+		func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(r.URL.Path, "/read/") {
+				atomic.AddInt32(reads, 1)
+				w.Write([]byte(data))
+			}
+		})
+}
+
+func TestReadNNTValuesReplicas(t *testing.T) {
+	var reads [3]int32
+	servers := []*httptest.Server{
+		newReplicaTestServer("aaaaaaaa-0000-0000-0000-000000000000",
+			"[[1380000900,9]]", &reads[0]),
+		newReplicaTestServer("bbbbbbbb-0000-0000-0000-000000000000",
+			"[[1380000000,1],[1380000300,2]]", &reads[1]),
+		newReplicaTestServer("cccccccc-0000-0000-0000-000000000000",
+			"[[1380000300,2],[1380000600,3]]", &reads[2]),
+	}
+	var addrs []string
+	for _, ts := range servers {
+		defer ts.Close()
+		addrs = append(addrs, ts.URL)
+	}
+	sc, err := NewSnowthClient(false, addrs...)
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	node := sc.ListActiveNodes()[0]
+
+	// the owners of metric "a" are bbbbbbbb, cccccccc then aaaaaaaa
+	data, err := sc.ReadNNTValuesReplicas(node, 2, ReplicaCombineMerge,
+		time.Unix(1380000000, 0), time.Unix(1380000900, 0), 300, "count",
+		ringTestUUID, "a")
+	if err != nil {
+		t.Fatal("error reading replicas: ", err)
+	}
+	assert.Equal(t, []NNTValue{
+		{Time: time.Unix(1380000000, 0), Value: 1, Float: 1},
+		{Time: time.Unix(1380000300, 0), Value: 2, Float: 2},
+		{Time: time.Unix(1380000600, 0), Value: 3, Float: 3},
+	}, data, "values of both replicas should be merged")
+	assert.Equal(t, [3]int32{0, 1, 1}, reads,
+		"only the first two owners should be queried")
+
+	data, err = sc.ReadNNTValuesReplicas(node, 2, ReplicaCombineMajority,
+		time.Unix(1380000000, 0), time.Unix(1380000900, 0), 300, "count",
+		ringTestUUID, "a")
+	if err != nil {
+		t.Fatal("error reading replicas: ", err)
+	}
+	assert.Equal(t, []NNTValue{
+		{Time: time.Unix(1380000300, 0), Value: 2, Float: 2},
+	}, data, "only values both replicas agree on should be kept")
+
+	_, err = sc.ReadNNTValuesReplicas(node, 4, ReplicaCombineFirst,
+		time.Unix(1380000000, 0), time.Unix(1380000900, 0), 300, "count",
+		ringTestUUID, "a")
+	assert.Error(t, err, "more replicas than owners should fail")
+}
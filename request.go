@@ -0,0 +1,49 @@
+package gosnowth
+
+import (
+	"context"
+	"io"
+	"net/url"
+)
+
+// Get - send a GET request for a path, with the query given, to a node,
+// returning the raw body of the response for the caller to parse.  This
+// allows use of node apis which the client does not otherwise support, with
+// the signing, rate limiting, failover and error handling of the client.
+func (sc *SnowthClient) Get(node *SnowthNode, path string,
+	query url.Values) ([]byte, error) {
+	return sc.GetContext(context.Background(), node, path, query)
+}
+
+// GetContext - make a GET request, as Get does, aborting the request when the
+// context given is done
+func (sc *SnowthClient) GetContext(ctx context.Context,
+	node *SnowthNode, path string, query url.Values) ([]byte, error) {
+
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	var b []byte
+	err := sc.doContext(ctx, node, "GET", path, nil, &b,
+		decodeRawFromResponse)
+	return b, err
+}
+
+// Post - send a POST request for a path, with the body given, to a node,
+// returning the raw body of the response for the caller to parse, as Get
+// does.  In dry-run mode, the request is not sent and no body is returned.
+func (sc *SnowthClient) Post(node *SnowthNode, path string,
+	body io.Reader) ([]byte, error) {
+	return sc.PostContext(context.Background(), node, path, body)
+}
+
+// PostContext - make a POST request, as Post does, aborting the request when
+// the context given is done
+func (sc *SnowthClient) PostContext(ctx context.Context,
+	node *SnowthNode, path string, body io.Reader) ([]byte, error) {
+
+	var b []byte
+	err := sc.doContext(ctx, node, "POST", path, body, &b,
+		decodeRawFromResponse)
+	return b, err
+}
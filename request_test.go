@@ -0,0 +1,50 @@
+package gosnowth
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPost(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/custom/get":
+			assert.Equal(t, "GET", r.Method)
+			w.Write([]byte(r.URL.Query().Get("q")))
+		case "/custom/post":
+			assert.Equal(t, "POST", r.Method)
+			b, _ := ioutil.ReadAll(r.Body)
+			w.Write(append([]byte("posted "), b...))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("not found"))
+		}
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	b, err := sc.Get(node, "/custom/get", url.Values{"q": {"a b"}})
+	if err != nil {
+		t.Fatal("error sending get: ", err)
+	}
+	assert.Equal(t, "a b", string(b))
+
+	b, err = sc.Post(node, "/custom/post", strings.NewReader("data"))
+	if err != nil {
+		t.Fatal("error sending post: ", err)
+	}
+	assert.Equal(t, "posted data", string(b))
+
+	_, err = sc.Get(node, "/custom/missing", nil)
+	if assert.Error(t, err, "an error status should be returned") {
+		se, ok := err.(*SnowthError)
+		if assert.True(t, ok, "the error should be a SnowthError") {
+			assert.Equal(t, http.StatusNotFound, se.StatusCode)
+		}
+	}
+}
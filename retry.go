@@ -0,0 +1,94 @@
+package gosnowth
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RetryPolicy - the policy for retrying requests to a node which failed
+// transiently, with a 429, 502, 503 or 504 response or a connection error.
+// A 429 response, from a node applying backpressure, is retried after the
+// same backoff as other transient failures.
+// Reads are retried, but writes are only retried if RetryWrites is set, as
+// they may not be idempotent.
+type RetryPolicy struct {
+	// MaxRetries is the number of times a request is retried, after the
+	// first attempt.  Zero disables retrying.
+	MaxRetries int
+	// Backoff is the time waited before the first retry, which is doubled
+	// for each retry after it.
+	Backoff time.Duration
+	// RetryWrites is whether requests other than GETs are retried.
+	RetryWrites bool
+}
+
+// defaultRetryPolicy - the retry policy of a client by default
+var defaultRetryPolicy = RetryPolicy{
+	MaxRetries: 2,
+	Backoff:    100 * time.Millisecond,
+}
+
+// WithRetryPolicy - set the policy for retrying requests which failed
+// transiently, in place of the default of retrying reads twice, after
+// 100ms and then 200ms.  A policy with no retries disables retrying.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(sc *SnowthClient) error {
+		if policy.MaxRetries < 0 {
+			return errors.New("negative max retries")
+		}
+		if policy.Backoff <= 0 {
+			policy.Backoff = defaultRetryPolicy.Backoff
+		}
+		sc.retry = policy
+		return nil
+	}
+}
+
+// isTransientError - whether a request failed in a way which may succeed if
+// the request is retried
+func isTransientError(err error) bool {
+	if se, ok := err.(*SnowthError); ok {
+		switch se.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	_, ok := errors.Cause(err).(net.Error)
+	return ok
+}
+
+// sendRetry - send a request to a node, retrying it with exponential backoff
+// when it fails transiently, if retry is set
+func (sc *SnowthClient) sendRetry(ctx context.Context, node *SnowthNode,
+	method, url string, bodyBytes []byte, header http.Header,
+	retry bool) (*http.Response, error) {
+
+	backoff := sc.retry.Backoff
+	for attempt := 0; ; attempt++ {
+		var body io.Reader
+		if bodyBytes != nil {
+			body = bytes.NewReader(bodyBytes)
+		}
+		resp, err := sc.sendOnce(ctx, node, method, url, body, header)
+		if err == nil || !retry || attempt >= sc.retry.MaxRetries ||
+			!isTransientError(err) {
+			return resp, err
+		}
+		sc.Logger.Warnf("transient failure of %s, retrying in %v: %v",
+			node.GetURL().Host, backoff, err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,131 @@
+package gosnowth
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	var requests, failures int32
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("[]"))
+	})
+	defer ts.Close()
+	newClient := func(opts ...ClientOption) (*SnowthClient, *SnowthNode) {
+		sc, err := NewSnowthClientWithOptions(false, []string{ts.URL}, opts...)
+		if err != nil {
+			t.Fatal("failed to create client: ", err)
+		}
+		return sc, sc.ListActiveNodes()[0]
+	}
+	reset := func(n int32) {
+		atomic.StoreInt32(&requests, 0)
+		atomic.StoreInt32(&failures, n)
+	}
+	read := func(sc *SnowthClient, node *SnowthNode) error {
+		_, err := sc.ReadNNTValues(node, time.Now(), time.Now(), 60,
+			"count", "id", "metric")
+		return err
+	}
+
+	sc, node := newClient(WithRetryPolicy(RetryPolicy{
+		MaxRetries: 2,
+		Backoff:    time.Millisecond,
+	}))
+	defer sc.Close()
+	reset(2)
+	assert.NoError(t, read(sc, node), "transient failures should be retried")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	reset(3)
+	assert.Error(t, read(sc, node), "retries should be limited")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	reset(1)
+	assert.Error(t, sc.WriteRaw(node, strings.NewReader("data"), false, 1),
+		"writes should not be retried by default")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	sc, node = newClient(WithRetryPolicy(RetryPolicy{
+		MaxRetries:  1,
+		Backoff:     time.Millisecond,
+		RetryWrites: true,
+	}))
+	defer sc.Close()
+	reset(1)
+	assert.NoError(t, sc.WriteRaw(node, strings.NewReader("data"), false, 1),
+		"writes should be retried when opted in")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	sc, node = newClient(WithRetryPolicy(RetryPolicy{}))
+	defer sc.Close()
+	reset(1)
+	assert.Error(t, read(sc, node), "retrying should be disabled")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestRetryPolicyTooManyRequests(t *testing.T) {
+	var requests int32
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("[]"))
+	})
+	defer ts.Close()
+	sc, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithRetryPolicy(RetryPolicy{MaxRetries: 1, Backoff: time.Millisecond}))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+	_, err = sc.ReadNNTValues(sc.ListActiveNodes()[0], time.Now(), time.Now(),
+		60, "count", "id", "metric")
+	assert.NoError(t, err, "backpressure should be retried")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestRetryPolicyWriteFailover(t *testing.T) {
+	var requests int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	first := newRingNodeTestServer("aaaaaaaa-0000-0000-0000-000000000000", 2,
+		handler)
+	defer first.Close()
+	second := newRingNodeTestServer("bbbbbbbb-0000-0000-0000-000000000000", 2,
+		handler)
+	defer second.Close()
+
+	for _, policy := range []RetryPolicy{
+		{},
+		{MaxRetries: 2, Backoff: time.Millisecond},
+	} {
+		sc, err := NewSnowthClientWithOptions(false,
+			[]string{first.URL, second.URL}, WithRetryPolicy(policy))
+		if err != nil {
+			t.Fatal("failed to create client: ", err)
+		}
+		defer sc.Close()
+		if len(sc.ListActiveNodes()) != 2 {
+			t.Fatal("both nodes should be active")
+		}
+		atomic.StoreInt32(&requests, 0)
+		assert.Error(t, sc.WriteRaw(sc.ListActiveNodes()[0],
+			strings.NewReader("data"), false, 1))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests),
+			"a failed write should not be resent to another node")
+	}
+}
@@ -0,0 +1,259 @@
+package gosnowth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MetricRef - a reference to a metric, by the uuid of the check it belongs
+// to and the name of the metric.
+type MetricRef struct {
+	ID     string
+	Metric string
+}
+
+// metricRing - the consistent hash ring of a topology, which determines
+// the nodes owning each metric.  Metrics are located on the ring by the
+// SHA-256 digest of the binary uuid followed by the metric name, the first
+// 32 bits of which are the position of the metric.  The owners of the metric
+// are the distinct nodes of the virtual nodes found walking the ring from
+// that position, up to the number of copies kept by the topology.
+type metricRing struct {
+	hash   string
+	copies int
+	vnodes []TopoRingDetail
+	nodes  map[string]TopologyNode
+	// fallback, when set, are the owners of every metric, used in place
+	// of the ring when no topology is known.
+	fallback []string
+}
+
+// newMetricRing - create the ring for a topology from its virtual nodes
+func newMetricRing(hash string, topology *Topology,
+	toporing *TopoRing) *metricRing {
+
+	nodes := make(map[string]TopologyNode)
+	for _, node := range topology.Nodes {
+		nodes[node.ID] = node
+	}
+	vnodes := make([]TopoRingDetail, 0, len(toporing.VirtualNodes))
+	for _, vnode := range toporing.VirtualNodes {
+		if _, ok := nodes[vnode.ID]; ok {
+			vnodes = append(vnodes, vnode)
+		}
+	}
+	sort.Slice(vnodes, func(i, j int) bool {
+		return vnodes[i].Location < vnodes[j].Location
+	})
+
+	copies := topology.NumberNodes
+	if copies <= 0 || copies > len(nodes) {
+		copies = len(nodes)
+	}
+	return &metricRing{hash: hash, copies: copies, vnodes: vnodes,
+		nodes: nodes}
+}
+
+// fetchMetricRing - fetch the topology and ring a node is currently using
+func (sc *SnowthClient) fetchMetricRing(ctx context.Context,
+	node *SnowthNode) (*metricRing, error) {
+	return sc.fetchMetricRingByHash(ctx, node, node.GetCurrentTopology())
+}
+
+// fetchMetricRingByHash - fetch the topology and ring with the hash given
+// from a node.  A topology never changes once created, so rings are cached
+// by hash, and each is only fetched once.
+func (sc *SnowthClient) fetchMetricRingByHash(ctx context.Context,
+	node *SnowthNode, hash string) (*metricRing, error) {
+	sc.ringsMu.Lock()
+	ring, ok := sc.rings[hash]
+	sc.ringsMu.Unlock()
+	if ok {
+		return ring, nil
+	}
+
+	topology, err := sc.getTopologyContext(ctx, node, hash)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get topology")
+	}
+	toporing, err := sc.GetTopoRingInfoContext(ctx, hash, node)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get toporing")
+	}
+	ring = newMetricRing(hash, topology, toporing)
+	sc.ringsMu.Lock()
+	defer sc.ringsMu.Unlock()
+	if sc.rings == nil {
+		sc.rings = make(map[string]*metricRing)
+	}
+	sc.rings[hash] = ring
+	return ring, nil
+}
+
+// NodesForMetric - find the active nodes owning a metric, in primary order,
+// according to the topology ring in use by the first active node of the
+// client, to route reads and writes of the metric to its owners.  The hash
+// of the topology used is returned with the nodes, so that callers may
+// detect when it has become stale.  When no topology is known, all active
+// nodes are returned, in the fallback node order, with an empty hash.  An
+// error listing the owners is returned if none of them are active.
+func (sc *SnowthClient) NodesForMetric(uuid,
+	metric string) ([]*SnowthNode, string, error) {
+	return sc.NodesForMetricContext(context.Background(), uuid, metric)
+}
+
+// NodesForMetricContext - find the active nodes owning a metric, as
+// NodesForMetric does, aborting the requests when the context given is done
+func (sc *SnowthClient) NodesForMetricContext(ctx context.Context,
+	uuid, metric string) ([]*SnowthNode, string, error) {
+
+	active := sc.ListActiveNodes()
+	if len(active) == 0 {
+		return nil, "", errors.New("no active nodes")
+	}
+	ring, err := sc.routingRing(ctx, active[0])
+	if err != nil {
+		return nil, "", err
+	}
+	nodes, err := sc.activeOwners(ring, uuid, metric)
+	if err != nil {
+		return nil, "", err
+	}
+	return nodes, ring.hash, nil
+}
+
+// activeOwners - the active nodes owning a metric on a ring, in primary
+// order, or an error listing the owners if none of them are active
+func (sc *SnowthClient) activeOwners(ring *metricRing,
+	uuid, metric string) ([]*SnowthNode, error) {
+	owners, err := ring.owners(uuid, sc.metricName(metric))
+	if err != nil {
+		return nil, err
+	}
+	var nodes []*SnowthNode
+	for _, owner := range owners {
+		if n := sc.findActiveNode(owner); n != nil {
+			nodes = append(nodes, n)
+		}
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no active owners of %s, owners are: %s",
+			metric, strings.Join(owners, ", "))
+	}
+	return nodes, nil
+}
+
+// routingRing - the ring used to route requests for metrics to the nodes
+// owning them, which is the ring in use by the node given, or when the node
+// has no known topology, a fallback ring on which every active node owns
+// every metric, in the fallback node order of the client.  A client created
+// with WithoutDiscovery always uses the fallback ring, as its seed nodes,
+// such as proxies, serve every metric whatever the owners on the ring.
+func (sc *SnowthClient) routingRing(ctx context.Context,
+	node *SnowthNode) (*metricRing, error) {
+	if sc.noDiscovery {
+		return &metricRing{fallback: sc.fallbackOwners()}, nil
+	}
+	hash := node.GetCurrentTopology()
+	if hash != "" {
+		ring, err := sc.fetchMetricRingByHash(ctx, node, hash)
+		if err == nil {
+			return ring, nil
+		}
+		if se, ok := errors.Cause(err).(*SnowthError); !ok ||
+			se.StatusCode != http.StatusNotFound {
+			return nil, err
+		}
+	}
+	sc.Logger.Warnf("no topology known by %s, routing to all active nodes",
+		node.GetURL().Host)
+	return &metricRing{fallback: sc.fallbackOwners()}, nil
+}
+
+// WithFallbackNodeOrder - set the order in which active nodes are used, by
+// node identifier, to route requests for metrics when no topology is known,
+// such as with nodes which do not support the topology api.  Active nodes
+// not listed follow those listed, in the order the client lists them.
+func WithFallbackNodeOrder(ids ...string) ClientOption {
+	return func(sc *SnowthClient) error {
+		sc.fallbackOrder = ids
+		return nil
+	}
+}
+
+// fallbackOwners - the identifiers of the active nodes, in the fallback node
+// order of the client
+func (sc *SnowthClient) fallbackOwners() []string {
+	var (
+		nodes = sc.ListActiveNodes()
+		rank  = make(map[string]int, len(sc.fallbackOrder))
+	)
+	for i, id := range sc.fallbackOrder {
+		if _, ok := rank[id]; !ok {
+			rank[id] = i
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		ri, iok := rank[nodes[i].identifier]
+		rj, jok := rank[nodes[j].identifier]
+		if iok && jok {
+			return ri < rj
+		}
+		return iok && !jok
+	})
+	result := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		result = append(result, n.identifier)
+	}
+	return result
+}
+
+// metricLocation - the position of a metric on the ring
+func metricLocation(id, metric string) (uint32, error) {
+	b, err := hex.DecodeString(strings.Replace(id, "-", "", -1))
+	if err != nil || len(b) != 16 {
+		return 0, fmt.Errorf("invalid metric uuid: %s", id)
+	}
+	h := sha256.New()
+	h.Write(b)
+	h.Write([]byte(metric))
+	return binary.BigEndian.Uint32(h.Sum(nil)[:4]), nil
+}
+
+// owners - the identifiers of the nodes owning a metric, in primary order
+func (mr *metricRing) owners(id, metric string) ([]string, error) {
+	if mr.fallback != nil {
+		return append([]string(nil), mr.fallback...), nil
+	}
+	if len(mr.vnodes) == 0 {
+		return nil, errors.New("topology ring has no nodes")
+	}
+	location, err := metricLocation(id, metric)
+	if err != nil {
+		return nil, err
+	}
+
+	start := sort.Search(len(mr.vnodes), func(i int) bool {
+		return mr.vnodes[i].Location >= float64(location)
+	})
+	var (
+		result = []string{}
+		seen   = make(map[string]bool)
+	)
+	for i := 0; i < len(mr.vnodes) && len(result) < mr.copies; i++ {
+		vnode := mr.vnodes[(start+i)%len(mr.vnodes)]
+		if !seen[vnode.ID] {
+			seen[vnode.ID] = true
+			result = append(result, vnode.ID)
+		}
+	}
+	return result, nil
+}
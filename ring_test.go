@@ -0,0 +1,192 @@
+package gosnowth
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestRing - decode the ring test data into a metric ring
+func newTestRing(t *testing.T) *metricRing {
+	topology, toporing := new(Topology), new(TopoRing)
+	if err := xml.NewDecoder(bytes.NewBufferString(
+		ringTopologyXMLTestData)).Decode(topology); err != nil {
+		t.Fatal("failed to decode topology: ", err)
+	}
+	if err := xml.NewDecoder(bytes.NewBufferString(
+		ringTopoRingXMLTestData)).Decode(toporing); err != nil {
+		t.Fatal("failed to decode toporing: ", err)
+	}
+	return newMetricRing("hash", topology, toporing)
+}
+
+// newRingNodeTestServer - create a test node with the identity given, one
+// of the nodes of the ring test topology, which serves the topology keeping
+// the number of copies given, and passes other requests to the handler
+func newRingNodeTestServer(identity string, copies int,
+	handler http.HandlerFunc) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == "/state":
+				w.Write([]byte(strings.Replace(stateTestData,
+					"bb6f7162-4828-11df-bab8-6bac200dcc2a", identity, 1)))
+			case strings.HasPrefix(r.URL.Path, "/topology/xml/"):
+				w.Write([]byte(strings.Replace(ringTopologyXMLTestData,
+					`n="2"`, fmt.Sprintf(`n="%d"`, copies), 1)))
+			case strings.HasPrefix(r.URL.Path, "/toporing/xml/"):
+				w.Write([]byte(ringTopoRingXMLTestData))
+			default:
+				handler(w, r)
+			}
+		}))
+}
+
+// ringTestHandler - serve the ring test topology and toporing
+func ringTestHandler(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/topology/xml/"):
+		w.Write([]byte(ringTopologyXMLTestData))
+	case strings.HasPrefix(r.URL.Path, "/toporing/xml/"):
+		w.Write([]byte(ringTopoRingXMLTestData))
+	}
+}
+
+func TestMetricRingOwners(t *testing.T) {
+	ring := newTestRing(t)
+
+	owners, err := ring.owners(ringTestUUID, "a")
+	if err != nil {
+		t.Fatal("error locating metric: ", err)
+	}
+	assert.Equal(t, []string{
+		"bbbbbbbb-0000-0000-0000-000000000000",
+		"cccccccc-0000-0000-0000-000000000000",
+	}, owners, "owners should follow the metric location")
+
+	owners, err = ring.owners(ringTestUUID, "c")
+	if err != nil {
+		t.Fatal("error locating metric: ", err)
+	}
+	assert.Equal(t, []string{
+		"cccccccc-0000-0000-0000-000000000000",
+		"aaaaaaaa-0000-0000-0000-000000000000",
+	}, owners, "owners should wrap around the ring")
+
+	_, err = ring.owners("not-a-uuid", "a")
+	assert.Error(t, err, "invalid uuids should not be located")
+}
+
+func TestRoutingRingFallback(t *testing.T) {
+	writes := make(chan string, 4)
+	newServer := func(identity string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/state":
+					w.Write([]byte(strings.Replace(stateTestData,
+						"bb6f7162-4828-11df-bab8-6bac200dcc2a", identity, 1)))
+				case r.URL.Path == "/write/nnt":
+					writes <- identity
+				default:
+					// nodes without the topology apis
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+	}
+	a := newServer("aaaaaaaa-0000-0000-0000-000000000000")
+	defer a.Close()
+	b := newServer("bbbbbbbb-0000-0000-0000-000000000000")
+	defer b.Close()
+	sc, err := NewSnowthClientWithOptions(false, []string{a.URL, b.URL},
+		WithFallbackNodeOrder("bbbbbbbb-0000-0000-0000-000000000000"))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	var node *SnowthNode
+	for _, n := range sc.ListActiveNodes() {
+		if n.identifier == "aaaaaaaa-0000-0000-0000-000000000000" {
+			node = n
+		}
+	}
+
+	ring, err := sc.routingRing(context.Background(), node)
+	if err != nil {
+		t.Fatal("error getting routing ring: ", err)
+	}
+	owners, err := ring.owners(ringTestUUID, "a")
+	if err != nil {
+		t.Fatal("error finding owners: ", err)
+	}
+	assert.Equal(t, []string{
+		"bbbbbbbb-0000-0000-0000-000000000000",
+		"aaaaaaaa-0000-0000-0000-000000000000",
+	}, owners, "all active nodes should own metrics in the fallback order")
+
+	bw := sc.NewBufferedWriter(node, 10)
+	if err := bw.WriteNNT(NNTData{ID: ringTestUUID, Metric: "a"}); err != nil {
+		t.Fatal("error buffering write: ", err)
+	}
+	if err := bw.Flush(); err != nil {
+		t.Fatal("error flushing writes: ", err)
+	}
+	assert.Equal(t, "bbbbbbbb-0000-0000-0000-000000000000", <-writes,
+		"writes should go to the first node in the fallback order")
+}
+
+func TestNodesForMetric(t *testing.T) {
+	var topologyFetches int32
+	var addrs []string
+	for _, id := range []string{
+		"aaaaaaaa-0000-0000-0000-000000000000",
+		"bbbbbbbb-0000-0000-0000-000000000000",
+		"cccccccc-0000-0000-0000-000000000000",
+	} {
+		ts := newRingNodeTestServer(id, 2, nil)
+		defer ts.Close()
+		ts.Config.Handler = countTopologyFetches(ts.Config.Handler,
+			&topologyFetches)
+		addrs = append(addrs, ts.URL)
+	}
+	sc, err := NewSnowthClient(false, addrs...)
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+
+	for i := 0; i < 2; i++ {
+		nodes, hash, err := sc.NodesForMetric(ringTestUUID, "a")
+		if err != nil {
+			t.Fatal("error finding nodes for metric: ", err)
+		}
+		assert.Equal(t, sc.ListActiveNodes()[0].GetCurrentTopology(), hash)
+		var ids []string
+		for _, n := range nodes {
+			ids = append(ids, n.identifier)
+		}
+		assert.Equal(t, []string{
+			"bbbbbbbb-0000-0000-0000-000000000000",
+			"cccccccc-0000-0000-0000-000000000000",
+		}, ids, "owners should be in primary order")
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&topologyFetches),
+		"the topology should be cached by hash")
+}
+
+// countTopologyFetches - wrap a handler, counting requests for topologies
+func countTopologyFetches(h http.Handler, count *int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/topology/xml/") {
+			atomic.AddInt32(count, 1)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,24 @@
+package gosnowth
+
+var ringTopologyXMLTestData = `<nodes n="2">
+	<node id="aaaaaaaa-0000-0000-0000-000000000000"
+		address="10.8.20.1" port="8112" apiport="8112" weight="1"/>
+	<node id="bbbbbbbb-0000-0000-0000-000000000000"
+		address="10.8.20.2" port="8112" apiport="8112" weight="1"/>
+	<node id="cccccccc-0000-0000-0000-000000000000"
+		address="10.8.20.3" port="8112" apiport="8112" weight="1"/>
+</nodes>`
+
+var ringTopoRingXMLTestData = `<vnodes n="2">
+	<vnode id="aaaaaaaa-0000-0000-0000-000000000000"
+		idx="0" location="1431655765.000000"/>
+	<vnode id="bbbbbbbb-0000-0000-0000-000000000000"
+		idx="0" location="2863311530.000000"/>
+	<vnode id="cccccccc-0000-0000-0000-000000000000"
+		idx="0" location="4294967295.000000"/>
+</vnodes>`
+
+// ringTestUUID - the check uuid of the metrics located in ring tests.  The
+// ring locations of its metrics "a", "b", "c" and "d" are 1758096486,
+// 631742297, 2935198804 and 1259566125.
+const ringTestUUID = "fc85e0ab-f568-45e6-86ee-d7443be8277d"
@@ -1,10 +1,12 @@
 package gosnowth
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
 	"path"
+	"sort"
 	"strings"
 	"time"
 )
@@ -25,7 +27,18 @@ func (rv *RollupValues) UnmarshalJSON(b []byte) error {
 
 // ReadRollupValues - Read Rollup data from a node
 func (sc *SnowthClient) ReadRollupValues(
-	node *SnowthNode, id, metric string, tags []string, rollup time.Duration, start, end time.Time) ([]RollupValues, error) {
+	node *SnowthNode, id, metric string, tags []string, rollup time.Duration, start, end time.Time,
+	opts ...ReadOption) ([]RollupValues, error) {
+	return sc.ReadRollupValuesContext(context.Background(), node, id,
+		metric, tags, rollup, start, end, opts...)
+}
+
+// ReadRollupValuesContext - read rollup data from a node, as
+// ReadRollupValues does, aborting the read when the context given is done
+func (sc *SnowthClient) ReadRollupValuesContext(ctx context.Context,
+	node *SnowthNode, id, metric string, tags []string,
+	rollup time.Duration, start, end time.Time,
+	opts ...ReadOption) ([]RollupValues, error) {
 
 	var (
 		start_ts = start.Unix() - start.Unix()%int64(rollup/time.Second)
@@ -33,7 +46,7 @@ func (sc *SnowthClient) ReadRollupValues(
 	)
 
 	var metricBuilder strings.Builder
-	metricBuilder.WriteString(metric)
+	metricBuilder.WriteString(sc.metricName(metric))
 	if len(tags) > 0 {
 		metricBuilder.WriteString("|ST[")
 		metricBuilder.WriteString(strings.Join(tags, ","))
@@ -41,11 +54,67 @@ func (sc *SnowthClient) ReadRollupValues(
 	}
 
 	var (
-		r   = []RollupValues{}
-		err = sc.do(node, "GET", fmt.Sprintf(
-			"%s?start_ts=%d&end_ts=%d&rollup_span=%ds",
-			path.Join("/rollup", id, url.QueryEscape(metricBuilder.String())), start_ts, end_ts,
-			int(rollup/time.Second)), nil, &r, decodeJSONFromResponse)
+		r   []RollupValues
+		ro  = newReadOptions(opts)
+		ref = MetricRef{ID: id, Metric: metricBuilder.String()}
 	)
-	return r, err
+	readFunc := func() (int, time.Time, error) {
+		r = []RollupValues{}
+		err := sc.doContext(ctx, node, "GET", fmt.Sprintf(
+			"%s?start_ts=%d&end_ts=%d&rollup_span=%ds",
+			path.Join("/rollup", id, url.QueryEscape(ref.Metric)),
+			start_ts, end_ts, int(rollup/time.Second)),
+			nil, &r, decodeJSONFromResponse)
+		if err == nil && ro.checkWindow {
+			d := r
+			r = d[:sc.trimToWindow(ref.Metric, time.Unix(start_ts, 0),
+				time.Unix(end_ts, 0), len(d),
+				func(i int) time.Time {
+					return time.Unix(d[i].Timestamp, 0)
+				},
+				func(dst, src int) { d[dst] = d[src] })]
+		}
+		if err != nil || len(r) == 0 {
+			return 0, time.Time{}, err
+		}
+		return len(r), time.Unix(r[len(r)-1].Timestamp, 0), nil
+	}
+	err := sc.read(ctx, ro, ref, end, int64(rollup/time.Second), readFunc)
+	return ro.processRollupValues(r), err
+}
+
+// ComputeRollup - compute rollup values from raw samples client-side, for
+// rollup periods which are not stored by the server.  The samples are
+// grouped into buckets of the period given, in seconds, aligned to the epoch
+// as server rollups are, and the samples in each bucket are combined with
+// the aggregate function named.  Only buckets containing samples are
+// returned, in time order.
+func ComputeRollup(raw []RawNumericValue, period int64,
+	agg string) ([]RollupValues, error) {
+
+	if period <= 0 {
+		return nil, fmt.Errorf("invalid rollup period: %d", period)
+	}
+	buckets := make(map[int64][]float64)
+	for _, rv := range raw {
+		ts := rv.Time.Unix()
+		ts -= ts % period
+		if ts > rv.Time.Unix() {
+			ts -= period
+		}
+		buckets[ts] = append(buckets[ts], rv.Value)
+	}
+
+	r := make([]RollupValues, 0, len(buckets))
+	for ts, values := range buckets {
+		v, err := aggregateValues(agg, values)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, RollupValues{Timestamp: ts, Value: v})
+	}
+	sort.Slice(r, func(i, j int) bool {
+		return r[i].Timestamp < r[j].Timestamp
+	})
+	return r, nil
 }
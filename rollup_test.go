@@ -3,6 +3,10 @@ package gosnowth
 import (
 	"bytes"
 	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 type mockHTTPClient struct {
@@ -61,3 +65,37 @@ func (nrc nooprc) Close() error {
 // 		t.Error(err.Error())
 // 	}
 // }
+
+func TestComputeRollup(t *testing.T) {
+	raw := []RawNumericValue{
+		{Time: time.Unix(1380000010, 0), Value: 4},
+		{Time: time.Unix(1380000030, 0), Value: 2},
+		{Time: time.Unix(1380000050, 0), Value: 6},
+		{Time: time.Unix(1380000400, 0), Value: 1},
+	}
+	tests := []struct {
+		agg      string
+		expected []float64
+	}{
+		{AggregateCount, []float64{3, 1}},
+		{AggregateAverage, []float64{4, 1}},
+		{AggregateMin, []float64{2, 1}},
+		{AggregateMax, []float64{6, 1}},
+		{AggregateSum, []float64{12, 1}},
+	}
+	for _, test := range tests {
+		r, err := ComputeRollup(raw, 300, test.agg)
+		if err != nil {
+			t.Fatal("error computing rollup: ", err)
+		}
+		assert.Equal(t, []RollupValues{
+			{Timestamp: 1380000000, Value: test.expected[0]},
+			{Timestamp: 1380000300, Value: test.expected[1]},
+		}, r, test.agg)
+	}
+
+	_, err := ComputeRollup(raw, 300, "bad")
+	assert.Error(t, err, "unknown aggregate should fail")
+	_, err = ComputeRollup(raw, 0, AggregateSum)
+	assert.Error(t, err, "invalid period should fail")
+}
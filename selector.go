@@ -0,0 +1,128 @@
+package gosnowth
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Selector - chooses the order in which the nodes able to serve a read are
+// tried, by the failover read helpers such as ReadNNTValuesAny, so that
+// reads may be spread across the nodes.  The first node of the order
+// returned is read from, and the rest are tried in turn if it fails.
+// Selectors are used concurrently, and must not modify the nodes given.
+type Selector interface {
+	Select(nodes []*SnowthNode) []*SnowthNode
+}
+
+// WithNodeSelector - choose the node each failover read is made from with
+// the selector given, in place of the default round-robin selector
+func WithNodeSelector(s Selector) ClientOption {
+	return func(sc *SnowthClient) error {
+		if s == nil {
+			return errors.New("nil node selector")
+		}
+		sc.selector = s
+		return nil
+	}
+}
+
+// selectNodes - order the nodes able to serve a read with the selector of
+// the client
+func (sc *SnowthClient) selectNodes(nodes []*SnowthNode) []*SnowthNode {
+	if sc.selector == nil || len(nodes) < 2 {
+		return nodes
+	}
+	return sc.selector.Select(nodes)
+}
+
+// ownerOrderSelector - a selector keeping the nodes in the order given
+type ownerOrderSelector struct{}
+
+// NewOwnerOrderSelector - create a selector which always reads from the
+// first node given, the primary owner of the metric, falling back to the
+// other owners in ring order
+func NewOwnerOrderSelector() Selector {
+	return ownerOrderSelector{}
+}
+
+// Select - the nodes in the order given
+func (ownerOrderSelector) Select(nodes []*SnowthNode) []*SnowthNode {
+	return nodes
+}
+
+// roundRobinSelector - a selector starting each read at the next node
+type roundRobinSelector struct {
+	mu   sync.Mutex
+	next int
+}
+
+// NewRoundRobinSelector - create a selector which starts each read at the
+// next of the nodes given in turn, the default of the client
+func NewRoundRobinSelector() Selector {
+	return &roundRobinSelector{}
+}
+
+// Select - the nodes rotated to start at the next node in turn
+func (rr *roundRobinSelector) Select(nodes []*SnowthNode) []*SnowthNode {
+	rr.mu.Lock()
+	start := rr.next % len(nodes)
+	rr.next++
+	rr.mu.Unlock()
+	result := make([]*SnowthNode, 0, len(nodes))
+	result = append(result, nodes[start:]...)
+	return append(result, nodes[:start]...)
+}
+
+// randomSelector - a selector trying the nodes in random order
+type randomSelector struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// NewRandomSelector - create a selector which tries the nodes given in a
+// random order for each read
+func NewRandomSelector() Selector {
+	return &randomSelector{
+		rnd: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Select - the nodes shuffled
+func (rs *randomSelector) Select(nodes []*SnowthNode) []*SnowthNode {
+	result := append([]*SnowthNode(nil), nodes...)
+	rs.mu.Lock()
+	rs.rnd.Shuffle(len(result), func(i, j int) {
+		result[i], result[j] = result[j], result[i]
+	})
+	rs.mu.Unlock()
+	return result
+}
+
+// lruSelector - a selector trying the least recently selected nodes first
+type lruSelector struct {
+	mu   sync.Mutex
+	used map[*SnowthNode]time.Time
+}
+
+// NewLeastRecentlyUsedSelector - create a selector which tries the nodes
+// given in order of when each was last selected first, least recently
+// first, with nodes never selected first of all
+func NewLeastRecentlyUsedSelector() Selector {
+	return &lruSelector{used: make(map[*SnowthNode]time.Time)}
+}
+
+// Select - the nodes ordered by when each was last selected first
+func (ls *lruSelector) Select(nodes []*SnowthNode) []*SnowthNode {
+	result := append([]*SnowthNode(nil), nodes...)
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	sort.SliceStable(result, func(i, j int) bool {
+		return ls.used[result[i]].Before(ls.used[result[j]])
+	})
+	ls.used[result[0]] = time.Now()
+	return result
+}
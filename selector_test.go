@@ -0,0 +1,101 @@
+package gosnowth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithNodeSelector(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		reads = make(map[string]int)
+	)
+	newServer := func(identity string) *httptest.Server {
+		return newRingNodeTestServer(identity, 2,
+			func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasPrefix(r.URL.Path, "/read/") {
+					mu.Lock()
+					reads[identity]++
+					mu.Unlock()
+					w.Write([]byte("[[1380000000,1]]"))
+				}
+			})
+	}
+	var addrs []string
+	for _, id := range []string{
+		"aaaaaaaa-0000-0000-0000-000000000000",
+		"bbbbbbbb-0000-0000-0000-000000000000",
+		"cccccccc-0000-0000-0000-000000000000",
+	} {
+		ts := newServer(id)
+		defer ts.Close()
+		addrs = append(addrs, ts.URL)
+	}
+	sc, err := NewSnowthClient(false, addrs...)
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+
+	// the owners of metric "a" are bbbbbbbb then cccccccc
+	for i := 0; i < 100; i++ {
+		_, _, err := sc.ReadNNTValuesAny(time.Unix(1380000000, 0),
+			time.Unix(1380000300, 0), 300, "count", ringTestUUID, "a")
+		if err != nil {
+			t.Fatal("error reading nnt values: ", err)
+		}
+	}
+	assert.Equal(t, map[string]int{
+		"bbbbbbbb-0000-0000-0000-000000000000": 50,
+		"cccccccc-0000-0000-0000-000000000000": 50,
+	}, reads, "reads should alternate between the owners")
+
+	sc, err = NewSnowthClientWithOptions(false, addrs,
+		WithNodeSelector(NewOwnerOrderSelector()))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+	_, node, err := sc.ReadNNTValuesAny(time.Unix(1380000000, 0),
+		time.Unix(1380000300, 0), 300, "count", ringTestUUID, "a")
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, "bbbbbbbb-0000-0000-0000-000000000000", node.identifier,
+		"the primary owner should be read from")
+
+	_, err = NewSnowthClientWithOptions(false, addrs, WithNodeSelector(nil))
+	assert.Error(t, err, "a nil selector should be rejected")
+}
+
+func TestSelectors(t *testing.T) {
+	nodes := []*SnowthNode{
+		{identifier: "a"}, {identifier: "b"}, {identifier: "c"},
+	}
+	for name, s := range map[string]Selector{
+		"round-robin":  NewRoundRobinSelector(),
+		"random":       NewRandomSelector(),
+		"least-recent": NewLeastRecentlyUsedSelector(),
+	} {
+		first := make(map[string]int)
+		for i := 0; i < 300; i++ {
+			order := s.Select(nodes)
+			assert.ElementsMatch(t, nodes, order,
+				"%s should order each node once", name)
+			first[order[0].identifier]++
+		}
+		for _, n := range nodes {
+			assert.True(t, first[n.identifier] > 50,
+				"%s should start %d of 300 reads at %s", name,
+				first[n.identifier], n.identifier)
+		}
+		assert.Equal(t, "a", nodes[0].identifier,
+			"%s should not modify the nodes given", name)
+	}
+}
@@ -0,0 +1,36 @@
+package gosnowth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestSigner - a function which signs a request before it is sent, given
+// the request and the contents of its body, attaching the signature to the
+// request, typically as a header.
+type RequestSigner func(r *http.Request, body []byte) error
+
+// WithRequestSigner - sign every request made by the client with the signer
+// given, such as for validation by an authenticating proxy in front of the
+// nodes.
+func WithRequestSigner(signer RequestSigner) ClientOption {
+	return func(sc *SnowthClient) error {
+		sc.signer = signer
+		return nil
+	}
+}
+
+// NewHMACSigner - create a request signer which computes an HMAC-SHA256,
+// using the key given, over the request path and query followed by the
+// request body, setting its hex encoding as the value of the header named.
+func NewHMACSigner(header string, key []byte) RequestSigner {
+	return func(r *http.Request, body []byte) error {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(r.URL.RequestURI()))
+		mac.Write(body)
+		r.Header.Set(header, hex.EncodeToString(mac.Sum(nil)))
+		return nil
+	}
+}
@@ -0,0 +1,44 @@
+package gosnowth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRequestSigner(t *testing.T) {
+	var (
+		key    = []byte("secret")
+		signed = 0
+	)
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte("/write/text"))
+		mac.Write(body)
+		assert.Equal(t, hex.EncodeToString(mac.Sum(nil)),
+			r.Header.Get("X-Signature"),
+			"signature should cover the path and body")
+		assert.Contains(t, string(body), "text-metric")
+		signed++
+	})
+	defer ts.Close()
+
+	sc, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithRequestSigner(NewHMACSigner("X-Signature", key)))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	err = sc.WriteText(sc.ListActiveNodes()[0], TextData{
+		Metric: "text-metric", ID: "id", Offset: "1380000000", Value: "a",
+	})
+	if err != nil {
+		t.Fatal("error writing text: ", err)
+	}
+	assert.Equal(t, 1, signed, "write should reach the server")
+}
@@ -0,0 +1,353 @@
+// Package snowthtest - provides an in-memory fake of a snowth cluster, for
+// testing code which uses the gosnowth client without a live cluster.
+package snowthtest
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/gosnowth"
+	"github.com/pkg/errors"
+)
+
+// metricKey - identifies the data of a metric within the fake
+type metricKey struct {
+	id     string
+	metric string
+}
+
+// Client - an in-memory fake of a snowth cluster consisting of a single
+// node, which implements gosnowth.SnowthAPI.  Data written to the fake is
+// stored as written, and served back by reads of the same metric within the
+// requested window, without being rolled up.  A Client is safe for
+// concurrent use.
+type Client struct {
+	mu         sync.Mutex
+	node       *gosnowth.SnowthNode
+	state      gosnowth.NodeState
+	nnt        map[metricKey][]gosnowth.NNTData
+	text       map[metricKey][]gosnowth.TextData
+	histograms map[metricKey][]gosnowth.HistogramData
+	raw        map[metricKey][]gosnowth.RawNumericData
+	tags       map[string][]gosnowth.FindTagsItem
+}
+
+// Client implements the SnowthAPI.
+var _ gosnowth.SnowthAPI = (*Client)(nil)
+
+// NewClient - create a new fake with no data stored
+func NewClient() *Client {
+	return &Client{
+		node:       &gosnowth.SnowthNode{},
+		state:      gosnowth.NodeState{Identity: "snowthtest"},
+		nnt:        make(map[metricKey][]gosnowth.NNTData),
+		text:       make(map[metricKey][]gosnowth.TextData),
+		histograms: make(map[metricKey][]gosnowth.HistogramData),
+		raw:        make(map[metricKey][]gosnowth.RawNumericData),
+		tags:       make(map[string][]gosnowth.FindTagsItem),
+	}
+}
+
+// SetFindTagsResult - set the items FindTags returns for a query
+func (c *Client) SetFindTagsResult(query string,
+	items ...gosnowth.FindTagsItem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tags[query] = items
+}
+
+// Histograms - return the histogram data written for a metric
+func (c *Client) Histograms(id, metric string) []gosnowth.HistogramData {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]gosnowth.HistogramData{},
+		c.histograms[metricKey{id, metric}]...)
+}
+
+// ListActiveNodes - list the single node of the fake
+func (c *Client) ListActiveNodes() []*gosnowth.SnowthNode {
+	return []*gosnowth.SnowthNode{c.node}
+}
+
+// ListInactiveNodes - list no nodes, the node of the fake is always active
+func (c *Client) ListInactiveNodes() []*gosnowth.SnowthNode {
+	return []*gosnowth.SnowthNode{}
+}
+
+// GetNodeState - get the state of the node of the fake
+func (c *Client) GetNodeState(
+	node *gosnowth.SnowthNode) (*gosnowth.NodeState, error) {
+	state := c.state
+	return &state, nil
+}
+
+// GetGossipInfo - get gossip showing the node of the fake as current
+func (c *Client) GetGossipInfo(
+	node *gosnowth.SnowthNode) (*gosnowth.Gossip, error) {
+	return &gosnowth.Gossip{{ID: c.state.Identity}}, nil
+}
+
+// GetTopologyInfo - get a topology consisting of the node of the fake
+func (c *Client) GetTopologyInfo(
+	node *gosnowth.SnowthNode) (*gosnowth.Topology, error) {
+	return &gosnowth.Topology{
+		NumberNodes: 1,
+		Nodes:       []gosnowth.TopologyNode{{ID: c.state.Identity}},
+	}, nil
+}
+
+// GetTopoRingInfo - get a ring consisting of the node of the fake
+func (c *Client) GetTopoRingInfo(hash string,
+	node *gosnowth.SnowthNode) (*gosnowth.TopoRing, error) {
+	return &gosnowth.TopoRing{
+		NumberNodes:  1,
+		VirtualNodes: []gosnowth.TopoRingDetail{{ID: c.state.Identity}},
+	}, nil
+}
+
+// LocateMetric - locate any metric on the node of the fake
+func (c *Client) LocateMetric(uuid string, metric string,
+	node *gosnowth.SnowthNode) (*gosnowth.DataLocation, error) {
+	topology, _ := c.GetTopologyInfo(node)
+	location := gosnowth.DataLocation(*topology)
+	return &location, nil
+}
+
+// FindTags - return the items set for the query with SetFindTagsResult
+func (c *Client) FindTags(node *gosnowth.SnowthNode, accountID int32,
+	query string, start, end string) ([]gosnowth.FindTagsItem, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]gosnowth.FindTagsItem{}, c.tags[query]...), nil
+}
+
+// WriteNNT - store NNT data
+func (c *Client) WriteNNT(node *gosnowth.SnowthNode,
+	data ...gosnowth.NNTData) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, d := range data {
+		k := metricKey{d.ID, d.Metric}
+		c.nnt[k] = append(c.nnt[k], d)
+	}
+	return nil
+}
+
+// WriteText - store text data
+func (c *Client) WriteText(node *gosnowth.SnowthNode,
+	data ...gosnowth.TextData) error {
+	for _, d := range data {
+		if _, err := strconv.ParseInt(d.Offset, 10, 64); err != nil {
+			return errors.Wrap(err, "invalid text data offset")
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, d := range data {
+		k := metricKey{d.ID, d.Metric}
+		c.text[k] = append(c.text[k], d)
+	}
+	return nil
+}
+
+// WriteHistogram - store histogram data
+func (c *Client) WriteHistogram(node *gosnowth.SnowthNode,
+	data ...gosnowth.HistogramData) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, d := range data {
+		k := metricKey{d.ID, d.Metric}
+		c.histograms[k] = append(c.histograms[k], d)
+	}
+	return nil
+}
+
+// WriteRaw - store raw numeric data, given as a JSON array of
+// gosnowth.RawNumericData records.  Flatbuffer data is not supported.
+func (c *Client) WriteRaw(node *gosnowth.SnowthNode, data io.Reader,
+	fb bool, dataPoints uint64) error {
+	if fb {
+		return errors.New("flatbuffer data is not supported")
+	}
+	records := []gosnowth.RawNumericData{}
+	if err := json.NewDecoder(data).Decode(&records); err != nil {
+		return errors.Wrap(err, "failed to decode raw data")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, d := range records {
+		k := metricKey{d.ID, d.Metric}
+		c.raw[k] = append(c.raw[k], d)
+	}
+	return nil
+}
+
+// inWindow - is the time, in seconds, within the window
+func inWindow(ts int64, start, end time.Time) bool {
+	return ts >= start.Unix() && ts <= end.Unix()
+}
+
+// ReadNNTValues - read the value of the NNT field named by the type, such as
+// "count" or "average", from each write of the metric within the window.
+// Read options are ignored.
+func (c *Client) ReadNNTValues(node *gosnowth.SnowthNode,
+	start, end time.Time, period int64, t, id, metric string,
+	opts ...gosnowth.ReadOption) ([]gosnowth.NNTValue, error) {
+	result := []gosnowth.NNTValue{}
+	for _, d := range c.readNNT(start, end, id, metric) {
+		var v int64
+		switch t {
+		case "count":
+			v = d.Count
+		case "average", "value":
+			v = d.Value
+		case "stddev":
+			v = d.StdDev
+		case "derive", "derivative":
+			v = d.Derivative
+		case "derive_stddev", "derivative_stddev":
+			v = d.DerivativeStdDev
+		case "counter":
+			v = d.Counter
+		case "counter_stddev":
+			v = d.CounterStdDev
+		default:
+			return nil, errors.Errorf("unknown nnt type: %s", t)
+		}
+		result = append(result, gosnowth.NNTValue{
+			Time:  time.Unix(d.Offset, 0),
+			Value: v,
+			Float: float64(v),
+		})
+	}
+	return result, nil
+}
+
+// ReadNNTAllValues - read every NNT field from each write of the metric
+// within the window.  Read options are ignored.
+func (c *Client) ReadNNTAllValues(node *gosnowth.SnowthNode,
+	start, end time.Time, period int64, id, metric string,
+	opts ...gosnowth.ReadOption) ([]gosnowth.NNTAllValue, error) {
+	result := []gosnowth.NNTAllValue{}
+	for _, d := range c.readNNT(start, end, id, metric) {
+		result = append(result, gosnowth.NNTAllValue{
+			Time:             time.Unix(d.Offset, 0),
+			Count:            d.Count,
+			Value:            d.Value,
+			StdDev:           d.StdDev,
+			Derivitive:       d.Derivative,
+			DerivitiveStdDev: d.DerivativeStdDev,
+			Counter:          d.Counter,
+			CounterStdDev:    d.CounterStdDev,
+		})
+	}
+	return result, nil
+}
+
+// readNNT - the NNT writes of a metric within a window, in time order
+func (c *Client) readNNT(start, end time.Time,
+	id, metric string) []gosnowth.NNTData {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result := []gosnowth.NNTData{}
+	for _, d := range c.nnt[metricKey{id, metric}] {
+		if inWindow(d.Offset, start, end) {
+			result = append(result, d)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Offset < result[j].Offset
+	})
+	return result
+}
+
+// ReadTextValues - read each text write of the metric within the window.
+// Read options are ignored.
+func (c *Client) ReadTextValues(node *gosnowth.SnowthNode,
+	start, end time.Time, id, metric string,
+	opts ...gosnowth.ReadOption) ([]gosnowth.TextValue, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result := []gosnowth.TextValue{}
+	for _, d := range c.text[metricKey{id, metric}] {
+		ts, _ := strconv.ParseInt(d.Offset, 10, 64)
+		if inWindow(ts, start, end) {
+			result = append(result, gosnowth.TextValue{
+				Time:  time.Unix(ts, 0),
+				Value: d.Value,
+			})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result, nil
+}
+
+// ReadHistogramValues - read each histogram write of the metric within the
+// window.  Read options are ignored.
+func (c *Client) ReadHistogramValues(node *gosnowth.SnowthNode,
+	start, end time.Time, period int64, id, metric string,
+	opts ...gosnowth.ReadOption) ([]gosnowth.HistogramValue, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result := []gosnowth.HistogramValue{}
+	for _, d := range c.histograms[metricKey{id, metric}] {
+		if inWindow(d.Offset, start, end) {
+			result = append(result, gosnowth.HistogramValue{
+				Time:   time.Unix(d.Offset, 0),
+				Period: d.Period,
+				Data:   d.Histogram,
+			})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result, nil
+}
+
+// ReadRollupValues - read the average of the raw data of the metric written
+// within each rollup period of the window.  The metric name is combined
+// with any tags given as it is by the client.  Read options are ignored.
+func (c *Client) ReadRollupValues(node *gosnowth.SnowthNode,
+	id, metric string, tags []string, rollup time.Duration,
+	start, end time.Time,
+	opts ...gosnowth.ReadOption) ([]gosnowth.RollupValues, error) {
+	if rollup < time.Second {
+		return nil, errors.New("invalid rollup span")
+	}
+	if len(tags) > 0 {
+		metric += "|ST[" + strings.Join(tags, ",") + "]"
+	}
+	span := int64(rollup / time.Second)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var (
+		sums   = make(map[int64]float64)
+		counts = make(map[int64]int)
+	)
+	for _, d := range c.raw[metricKey{id, metric}] {
+		if ts := d.Offset / 1000; inWindow(ts, start, end) {
+			sums[ts-ts%span] += d.Value
+			counts[ts-ts%span]++
+		}
+	}
+	result := []gosnowth.RollupValues{}
+	for ts, sum := range sums {
+		result = append(result, gosnowth.RollupValues{
+			Timestamp: ts,
+			Value:     sum / float64(counts[ts]),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Timestamp < result[j].Timestamp
+	})
+	return result, nil
+}
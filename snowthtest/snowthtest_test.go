@@ -0,0 +1,64 @@
+package snowthtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/gosnowth"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordLatency - an example of application code written against the
+// SnowthAPI, which a fake may be substituted into.
+func recordLatency(api gosnowth.SnowthAPI, id string, at time.Time,
+	ms int64) error {
+	for _, node := range api.ListActiveNodes() {
+		if err := api.WriteNNT(node, gosnowth.NNTData{
+			Metric: "latency", ID: id, Offset: at.Unix(),
+			Count: 1, Value: ms,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestClient(t *testing.T) {
+	var (
+		fake = NewClient()
+		now  = time.Unix(1380000000, 0)
+	)
+	if err := recordLatency(fake, "id", now, 42); err != nil {
+		t.Fatal("error writing to fake: ", err)
+	}
+	err := fake.WriteText(fake.ListActiveNodes()[0], gosnowth.TextData{
+		Metric: "version", ID: "id", Offset: "1380000000", Value: "1.0.0",
+	})
+	if err != nil {
+		t.Fatal("error writing to fake: ", err)
+	}
+
+	node := fake.ListActiveNodes()[0]
+	values, err := fake.ReadNNTValues(node, now.Add(-time.Minute),
+		now.Add(time.Minute), 60, "average", "id", "latency")
+	if err != nil {
+		t.Fatal("error reading from fake: ", err)
+	}
+	assert.Equal(t, []gosnowth.NNTValue{{Time: now, Value: 42, Float: 42}},
+		values, "fake should serve the value written")
+
+	text, err := fake.ReadTextValues(node, now.Add(-time.Minute),
+		now.Add(time.Minute), "id", "version")
+	if err != nil {
+		t.Fatal("error reading from fake: ", err)
+	}
+	assert.Equal(t, []gosnowth.TextValue{{Time: now, Value: "1.0.0"}}, text,
+		"fake should serve the text written")
+
+	values, err = fake.ReadNNTValues(node, now.Add(time.Minute),
+		now.Add(time.Hour), 60, "average", "id", "latency")
+	if err != nil {
+		t.Fatal("error reading from fake: ", err)
+	}
+	assert.Empty(t, values, "fake should not serve values outside the window")
+}
@@ -1,14 +1,23 @@
 package gosnowth
 
 import (
+	"context"
 	"encoding/json"
 	"strings"
 )
 
 // GetNodeState - Get the node state from the client.
 func (sc *SnowthClient) GetNodeState(node *SnowthNode) (state *NodeState, err error) {
+	return sc.GetNodeStateContext(context.Background(), node)
+}
+
+// GetNodeStateContext - get the node state, as GetNodeState does, aborting
+// the request when the context given is done
+func (sc *SnowthClient) GetNodeStateContext(ctx context.Context,
+	node *SnowthNode) (state *NodeState, err error) {
 	state = new(NodeState)
-	err = sc.do(node, "GET", "/state", nil, state, decodeJSONFromResponse)
+	err = sc.doContext(ctx, node, "GET", "/state", nil, state,
+		decodeJSONFromResponse)
 	return
 }
 
@@ -44,6 +53,9 @@ type NodeState struct {
 
 	Version     string `json:"version"`
 	Application string `json:"application"`
+	// Semver is the release of the node, such as "0.23.5", which is
+	// reported by newer nodes only.
+	Semver string `json:"semver"`
 }
 
 // Rollup - the structure that defines the rollup (nnt,text,histogram) from api
@@ -115,7 +127,18 @@ type Features struct {
 	NNTSecondOrder          bool `json:"nnt:second_order"`
 	HistogramDynamicRollups bool `json:"hisogram:dynamic_rollups"`
 	NNTStore                bool `json:"nnt:store"`
+	RawStore                bool `json:"raw:store"`
 	FeatureFlags            bool `json:"features"`
+
+	// supported holds every feature reported by the node, including those
+	// without a field.
+	supported map[string]bool
+}
+
+// Has - whether the node reported supporting the feature named, such as
+// "nnt:store", including features which have no field
+func (f Features) Has(name string) bool {
+	return f.supported[name]
 }
 
 // UnmarshalJSON - conversion from the string 1/0 representation to bool
@@ -125,7 +148,9 @@ func (f *Features) UnmarshalJSON(b []byte) error {
 	f.NNTSecondOrder = false
 	f.HistogramDynamicRollups = false
 	f.NNTStore = false
+	f.RawStore = false
 	f.FeatureFlags = false
+	f.supported = make(map[string]bool)
 
 	m := make(map[string]string)
 	err := json.Unmarshal(b, &m)
@@ -134,6 +159,7 @@ func (f *Features) UnmarshalJSON(b []byte) error {
 	}
 
 	for k, v := range m {
+		f.supported[k] = v == "1"
 		switch k {
 		case "text:store":
 			if v == "1" {
@@ -160,6 +186,11 @@ func (f *Features) UnmarshalJSON(b []byte) error {
 				f.NNTStore = true
 			}
 			break
+		case "raw:store":
+			if v == "1" {
+				f.RawStore = true
+			}
+			break
 		case "features":
 			if v == "1" {
 				f.FeatureFlags = true
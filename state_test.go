@@ -22,4 +22,9 @@ func TestStateDeserialization(t *testing.T) {
 	assert.Equal(t, "294cbd39999c2270964029691e8bc5e231a867d525ccba62181dc8988ff218dc", state.Current, "should equal")
 	assert.Equal(t, uint64(60), state.BaseRollup, "should equal")
 	assert.Equal(t, 4, len(state.NNT.RollupEntries), "should equal")
+	assert.Equal(t, "0.23.5", state.Semver)
+	assert.Equal(t, "snowth", state.Application)
+	assert.True(t, state.Features.RawStore)
+	assert.True(t, state.Features.Has("histogram:dynamic_rollups"))
+	assert.False(t, state.Features.Has("unknown"))
 }
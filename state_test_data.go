@@ -198,5 +198,6 @@ var stateTestData = `{
 	 "features":"1"
  },
  "version":"v52bcc96a9a1a41acd96352b9b63e59cba2b6a8a9\/65ab82cb7281e76e96b2fedafdc6594d50437d91",
- "application":"snowth"
+ "application":"snowth",
+ "semver":"0.23.5"
 }`
@@ -1,10 +1,19 @@
 package gosnowth
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
+	"time"
 )
 
+// defaultMaxAggregateMetrics - the limit on the number of metrics matched by
+// a tag query which are aggregated, when no limit is given.
+const defaultMaxAggregateMetrics = 100
+
 type FindTagsItem struct {
 	UUID       string
 	CheckName  string `json:"check_name"`
@@ -12,25 +21,281 @@ type FindTagsItem struct {
 	Category   string
 	Type       string
 	AccountID  int32 `json:"account_id"`
+	// Activity holds the ranges of time, as pairs of start and end times in
+	// seconds since the epoch, during which the metric received data.
+	Activity [][]int64 `json:"activity"`
+}
+
+// LastActive - the end of the latest range of time during which the metric
+// received data, or the zero time if no activity was reported
+func (fti *FindTagsItem) LastActive() time.Time {
+	var last int64
+	for _, r := range fti.Activity {
+		if len(r) == 2 && r[1] > last {
+			last = r[1]
+		}
+	}
+	if last == 0 {
+		return time.Time{}
+	}
+	return time.Unix(last, 0)
 }
 
 // FindTags - Find metrics that are associated with tags
 func (sc *SnowthClient) FindTags(node *SnowthNode, accountID int32, query string, start, end string) ([]FindTagsItem, error) {
+	return sc.FindTagsContext(context.Background(), node, accountID, query,
+		start, end)
+}
+
+// FindTagsContext - find metrics associated with tags, as FindTags does,
+// aborting the request when the context given is done
+func (sc *SnowthClient) FindTagsContext(ctx context.Context,
+	node *SnowthNode, accountID int32, query string,
+	start, end string) ([]FindTagsItem, error) {
+
 	var u string
 	if start == "" || end == "" {
 		u = fmt.Sprintf("%s?query=%s",
-			sc.getURL(node, fmt.Sprintf("/find/%d/tags", accountID)),
+			fmt.Sprintf("/find/%d/tags", accountID),
 			url.QueryEscape(query),
 		)
 	} else {
 		u = fmt.Sprintf("%s?query=%s&activity_start_secs=%s&activity_end_secs=%s",
-			sc.getURL(node, fmt.Sprintf("/find/%d/tags", accountID)),
+			fmt.Sprintf("/find/%d/tags", accountID),
 			url.QueryEscape(query), url.QueryEscape(start), url.QueryEscape(end),
 		)
 	}
 	var (
 		r   = []FindTagsItem{}
-		err = sc.do(node, "GET", u, nil, &r, decodeJSONFromResponse)
+		err = sc.doContext(ctx, node, "GET", u, nil, &r,
+			decodeJSONFromResponse)
+	)
+	kept := r[:0]
+	for _, item := range r {
+		name, ok := sc.stripMetricPrefix(item.MetricName)
+		if !ok {
+			continue
+		}
+		item.MetricName = name
+		kept = append(kept, item)
+	}
+	return kept, err
+}
+
+// MetricName - the name of a metric, including its stream tags, and the
+// uuid of the check it belongs to, which together identify the metric in
+// reads
+type MetricName struct {
+	UUID   string
+	Metric string
+}
+
+// FindMetrics - find the metrics of an account matching a tag query, such as
+// "and(__name:cpu*,service:api)", to discover the uuid and metric name pairs
+// needed by the read methods.  Snowth does not page the results of a search.
+// Instead, when limit is positive, it is passed to the node as an advisory
+// limit on the number of metrics returned.
+func (sc *SnowthClient) FindMetrics(node *SnowthNode, accountID int32,
+	query string, limit int) ([]MetricName, error) {
+	return sc.FindMetricsContext(context.Background(), node, accountID,
+		query, limit)
+}
+
+// FindMetricsContext - find the metrics of an account matching a tag query, as
+// FindMetrics does, aborting the request when the context given is done
+func (sc *SnowthClient) FindMetricsContext(ctx context.Context,
+	node *SnowthNode, accountID int32, query string,
+	limit int) ([]MetricName, error) {
+
+	u := fmt.Sprintf("/find/%d/tags?query=%s", accountID,
+		url.QueryEscape(query))
+	header := http.Header{}
+	if limit > 0 {
+		header.Set("X-Snowth-Advisory-Limit", strconv.Itoa(limit))
+	}
+	items := []FindTagsItem{}
+	if err := sc.doWithHeadersContext(ctx, node, "GET", u, nil, header,
+		&items, decodeJSONFromResponse); err != nil {
+		return nil, err
+	}
+	result := make([]MetricName, 0, len(items))
+	for _, item := range items {
+		name, ok := sc.stripMetricPrefix(item.MetricName)
+		if !ok {
+			continue
+		}
+		result = append(result, MetricName{UUID: item.UUID, Metric: name})
+	}
+	return result, nil
+}
+
+// FindRecentMetrics - find the metrics matching a tag query which received
+// data within the lookback window ending now, to verify that ingestion is
+// live.  The metrics are found with a tag query limited to the window, and
+// those whose reported activity ended before the window are excluded, as
+// activity is tracked by the node at a coarser granularity.
+func (sc *SnowthClient) FindRecentMetrics(node *SnowthNode, accountID int32,
+	query string, lookback time.Duration) ([]FindTagsItem, error) {
+	return sc.FindRecentMetricsContext(context.Background(), node,
+		accountID, query, lookback)
+}
+
+// FindRecentMetricsContext - find the metrics recently receiving data, as
+// FindRecentMetrics does, aborting the request when the context given is done
+func (sc *SnowthClient) FindRecentMetricsContext(ctx context.Context,
+	node *SnowthNode, accountID int32, query string,
+	lookback time.Duration) ([]FindTagsItem, error) {
+
+	var (
+		end   = time.Now()
+		start = end.Add(-lookback)
 	)
-	return r, err
+	items, err := sc.FindTagsContext(ctx, node, accountID, query,
+		strconv.FormatInt(start.Unix(), 10), strconv.FormatInt(end.Unix(), 10))
+	if err != nil {
+		return nil, err
+	}
+	result := []FindTagsItem{}
+	for _, item := range items {
+		if len(item.Activity) > 0 && item.LastActive().Before(start) {
+			continue
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// ReadAggregateByTags - read the NNT values of every metric matching a tag
+// query, active within the window given, and combine them into a single
+// series using the aggregate function named, such as AggregateSum.  Values
+// are combined per timestamp, across the metrics which have a value for that
+// timestamp.  An error is returned if the query matches more metrics than
+// maxMetrics, or than a default limit if maxMetrics is not positive.
+func (sc *SnowthClient) ReadAggregateByTags(node *SnowthNode, accountID int32,
+	query string, start, end time.Time, period int64, t, fn string,
+	maxMetrics int) ([]NNTValue, error) {
+	return sc.ReadAggregateByTagsContext(context.Background(), node,
+		accountID, query, start, end, period, t, fn, maxMetrics)
+}
+
+// ReadAggregateByTagsContext - read and aggregate the metrics matching a tag
+// query, as ReadAggregateByTags does, aborting the reads when the context given
+// is done
+func (sc *SnowthClient) ReadAggregateByTagsContext(ctx context.Context,
+	node *SnowthNode, accountID int32, query string, start, end time.Time,
+	period int64, t, fn string, maxMetrics int) ([]NNTValue, error) {
+
+	if _, err := aggregateValues(fn, nil); err != nil {
+		return nil, err
+	}
+	items, err := sc.findTagsLimited(ctx, node, accountID, query, start,
+		end, maxMetrics)
+	if err != nil {
+		return nil, err
+	}
+
+	grouped := make(map[int64][]float64)
+	for _, item := range items {
+		values, err := sc.ReadNNTValuesContext(ctx, node, start, end,
+			period, t, item.UUID, item.MetricName)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			ts := v.Time.Unix()
+			grouped[ts] = append(grouped[ts], v.Float)
+		}
+	}
+
+	result := make([]NNTValue, 0, len(grouped))
+	for ts, values := range grouped {
+		v, _ := aggregateValues(fn, values)
+		result = append(result, newNNTValue(time.Unix(ts, 0), v))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result, nil
+}
+
+// ReadHistogramMergedByTags - read the histograms of every metric matching a
+// tag query, active within the window given, and merge them into a single
+// histogram per period, such as to find service-wide latency quantiles with
+// the Quantiles method of the values returned.  An error is returned if the
+// query matches more metrics than maxMetrics, or than a default limit if
+// maxMetrics is not positive.
+func (sc *SnowthClient) ReadHistogramMergedByTags(node *SnowthNode,
+	accountID int32, query string, start, end time.Time, period int64,
+	maxMetrics int) ([]HistogramValue, error) {
+	return sc.ReadHistogramMergedByTagsContext(context.Background(), node,
+		accountID, query, start, end, period, maxMetrics)
+}
+
+// ReadHistogramMergedByTagsContext - read and merge the histograms matching a
+// tag query, as ReadHistogramMergedByTags does, aborting the reads when the
+// context given is done
+func (sc *SnowthClient) ReadHistogramMergedByTagsContext(ctx context.Context,
+	node *SnowthNode, accountID int32, query string, start, end time.Time,
+	period int64, maxMetrics int) ([]HistogramValue, error) {
+
+	items, err := sc.findTagsLimited(ctx, node, accountID, query, start,
+		end, maxMetrics)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[int64]*HistogramValue)
+	for _, item := range items {
+		values, err := sc.ReadHistogramValuesContext(ctx, node, start,
+			end, period, item.UUID, item.MetricName)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			m, ok := merged[v.Time.Unix()]
+			if !ok {
+				merged[v.Time.Unix()] = &HistogramValue{
+					Time:   v.Time,
+					Period: v.Period,
+					Data:   v.Data.Copy(),
+				}
+				continue
+			}
+			if err := mergeHistogram(m.Data, v.Data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result := make([]HistogramValue, 0, len(merged))
+	for _, v := range merged {
+		result = append(result, *v)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result, nil
+}
+
+// findTagsLimited - find the metrics matching a tag query, active within the
+// window given, failing if more than maxMetrics are matched, or more than a
+// default limit if maxMetrics is not positive
+func (sc *SnowthClient) findTagsLimited(ctx context.Context,
+	node *SnowthNode, accountID int32, query string, start, end time.Time,
+	maxMetrics int) ([]FindTagsItem, error) {
+
+	if maxMetrics <= 0 {
+		maxMetrics = defaultMaxAggregateMetrics
+	}
+	items, err := sc.FindTagsContext(ctx, node, accountID, query,
+		strconv.FormatInt(start.Unix(), 10), strconv.FormatInt(end.Unix(), 10))
+	if err != nil {
+		return nil, err
+	}
+	if len(items) > maxMetrics {
+		return nil, fmt.Errorf(
+			"tag query matched %d metrics, more than the limit of %d",
+			len(items), maxMetrics)
+	}
+	return items, nil
 }
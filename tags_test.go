@@ -0,0 +1,135 @@
+package gosnowth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadAggregateByTags(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/find/1/tags":
+			assert.Equal(t, "and(service:api)", r.URL.Query().Get("query"))
+			w.Write([]byte(`[
+				{"uuid":"id1","check_name":"a","metric_name":"latency"},
+				{"uuid":"id2","check_name":"b","metric_name":"latency"}
+			]`))
+		case strings.Contains(r.URL.Path, "/id1/"):
+			w.Write([]byte("[[1380000000,1],[1380000060,2]]"))
+		case strings.Contains(r.URL.Path, "/id2/"):
+			w.Write([]byte("[[1380000000,10],[1380000060,20]]"))
+		}
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	data, err := sc.ReadAggregateByTags(node, 1, "and(service:api)",
+		time.Unix(1380000000, 0), time.Unix(1380000060, 0), 60, "average",
+		AggregateSum, 10)
+	if err != nil {
+		t.Fatal("error reading aggregate: ", err)
+	}
+	assert.Equal(t, []NNTValue{
+		{Time: time.Unix(1380000000, 0), Value: 11, Float: 11},
+		{Time: time.Unix(1380000060, 0), Value: 22, Float: 22},
+	}, data, "matched metrics should be summed")
+
+	_, err = sc.ReadAggregateByTags(node, 1, "and(service:api)",
+		time.Unix(1380000000, 0), time.Unix(1380000060, 0), 60, "average",
+		AggregateSum, 1)
+	assert.Error(t, err, "matching more metrics than the limit should fail")
+}
+
+func TestReadHistogramMergedByTags(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/find/1/tags":
+			w.Write([]byte(`[
+				{"uuid":"id1","check_name":"a","metric_name":"latency"},
+				{"uuid":"id2","check_name":"b","metric_name":"latency"}
+			]`))
+		case strings.Contains(r.URL.Path, "/id1/"):
+			w.Write([]byte(`[[1380000000,60,{"+10e-001":2,"+20e-001":1}],` +
+				`[1380000060,60,{"+30e-001":1}]]`))
+		case strings.Contains(r.URL.Path, "/id2/"):
+			w.Write([]byte(`[[1380000000,60,{"+20e-001":1}]]`))
+		}
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	data, err := sc.ReadHistogramMergedByTags(node, 1, "and(service:api)",
+		time.Unix(1380000000, 0), time.Unix(1380000060, 0), 60, 10)
+	if err != nil {
+		t.Fatal("error reading merged histograms: ", err)
+	}
+	assert.Equal(t, 2, len(data))
+	assert.Equal(t, time.Unix(1380000000, 0), data[0].Time)
+	assert.Equal(t, []string{"H[1.0e+00]=2", "H[2.0e+00]=2"},
+		data[0].Data.DecStrings(), "histograms should be merged")
+	assert.Equal(t, []string{"H[3.0e+00]=1"}, data[1].Data.DecStrings())
+
+	q, err := data[0].Quantiles(0, 1)
+	if err != nil {
+		t.Fatal("error computing quantiles: ", err)
+	}
+	assert.Equal(t, 2, len(q))
+	assert.True(t, q[0] >= 1 && q[0] < 1.1, "minimum should be in first bin")
+	assert.True(t, q[1] >= 2 && q[1] <= 2.1, "maximum should be in last bin")
+
+	_, err = sc.ReadHistogramMergedByTags(node, 1, "and(service:api)",
+		time.Unix(1380000000, 0), time.Unix(1380000060, 0), 60, 1)
+	assert.Error(t, err, "matching more metrics than the limit should fail")
+}
+
+func TestFindRecentMetrics(t *testing.T) {
+	now := time.Now().Unix()
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/find/1/tags", r.URL.Path)
+		assert.NotEmpty(t, r.URL.Query().Get("activity_start_secs"))
+		fmt.Fprintf(w, `[
+			{"uuid":"id1","metric_name":"live","activity":[[%d,%d],[%d,%d]]},
+			{"uuid":"id2","metric_name":"stale","activity":[[%d,%d]]}
+		]`, now-86400, now-80000, now-600, now-60, now-7200, now-3600)
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	items, err := sc.FindRecentMetrics(node, 1, "and(__name:*)",
+		15*time.Minute)
+	if err != nil {
+		t.Fatal("error finding recent metrics: ", err)
+	}
+	if assert.Equal(t, 1, len(items)) {
+		assert.Equal(t, "live", items[0].MetricName)
+		assert.Equal(t, time.Unix(now-60, 0), items[0].LastActive())
+	}
+}
+
+func TestFindMetrics(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/find/1/tags", r.URL.Path)
+		assert.Equal(t, "and(__name:cpu*)", r.URL.Query().Get("query"))
+		assert.Equal(t, "2", r.Header.Get("X-Snowth-Advisory-Limit"))
+		w.Write([]byte(`[
+			{"uuid":"id1","check_name":"a","metric_name":"cpu|ST[a:b]"},
+			{"uuid":"id2","check_name":"b","metric_name":"cpu_idle"}
+		]`))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	metrics, err := sc.FindMetrics(node, 1, "and(__name:cpu*)", 2)
+	if err != nil {
+		t.Fatal("error finding metrics: ", err)
+	}
+	assert.Equal(t, []MetricName{
+		{UUID: "id1", Metric: "cpu|ST[a:b]"},
+		{UUID: "id2", Metric: "cpu_idle"},
+	}, metrics)
+}
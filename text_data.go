@@ -2,7 +2,10 @@ package gosnowth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"path"
 	"strconv"
 	"time"
@@ -10,34 +13,237 @@ import (
 	"github.com/pkg/errors"
 )
 
-// WriteText - Write Text data to a node, data should be a slice of TextData
-// and node is the node to write the data to
+// WriteText - Write Text data to a node, the node to write to is given
+// first, followed by any number of TextData entries.  Every entry is
+// validated before anything is written, and an error is returned if any
+// lacks a metric or uuid, or has an offset which is not in seconds.  A
+// PartialWriteError is returned if the node rejects some of the entries.
 func (sc *SnowthClient) WriteText(node *SnowthNode, data ...TextData) (err error) {
+	return sc.WriteTextContext(context.Background(), node, data...)
+}
+
+// WriteTextRouted - write text data to the nodes owning it, rather than to
+// a node chosen by the caller.  The data is grouped by the first active
+// owner of each metric, according to the topology ring in use by the first
+// active node of the client, as found by NodesForMetric, and each group is
+// written to its owner in a single request.  Every group is written even if
+// others fail, and the failures are returned together.
+func (sc *SnowthClient) WriteTextRouted(data ...TextData) error {
+	return sc.WriteTextRoutedContext(context.Background(), data...)
+}
+
+// WriteTextRoutedContext - write text data to the owners of its metrics, as
+// WriteTextRouted does, aborting the writes when the context given is done
+func (sc *SnowthClient) WriteTextRoutedContext(ctx context.Context,
+	data ...TextData) error {
+
+	active := sc.ListActiveNodes()
+	if len(active) == 0 {
+		return errors.New("no active nodes")
+	}
+	ring, err := sc.routingRing(ctx, active[0])
+	if err != nil {
+		return err
+	}
+
+	var (
+		mErr   = newMultiError()
+		groups = make(map[*SnowthNode][]TextData)
+		order  []*SnowthNode
+	)
+	for _, d := range data {
+		owners, err := sc.activeOwners(ring, d.ID, d.Metric)
+		if err != nil {
+			mErr.Add(errors.Wrapf(err, "failed to route %s", d.Metric))
+			continue
+		}
+		if _, ok := groups[owners[0]]; !ok {
+			order = append(order, owners[0])
+		}
+		groups[owners[0]] = append(groups[owners[0]], d)
+	}
+	for _, owner := range order {
+		err := sc.WriteTextContext(ctx, owner, groups[owner]...)
+		if err != nil {
+			mErr.Add(errors.Wrapf(err, "failed to write to %s",
+				owner.GetURL().Host))
+		}
+	}
+	if mErr.HasError() {
+		return mErr
+	}
+	return nil
+}
+
+// WriteTextContext - write text data to a node, as WriteText does, aborting
+// the write when the context given is done
+func (sc *SnowthClient) WriteTextContext(ctx context.Context, node *SnowthNode,
+	data ...TextData) (err error) {
+	_, err = sc.writeText(ctx, node, data)
+	return
+}
+
+// WriteTextWithResult - write text data to a node, as WriteText does,
+// returning what the node reports accepting, so that ingestion may detect
+// records which were rejected.  When the node rejects some of the records,
+// the result is returned along with a PartialWriteError.
+func (sc *SnowthClient) WriteTextWithResult(node *SnowthNode,
+	data ...TextData) (*WriteResult, error) {
+	return sc.WriteTextWithResultContext(context.Background(), node,
+		data...)
+}
+
+// WriteTextWithResultContext - write text data to a node, as
+// WriteTextWithResult does, aborting the write when the context given is done
+func (sc *SnowthClient) WriteTextWithResultContext(ctx context.Context,
+	node *SnowthNode, data ...TextData) (*WriteResult, error) {
+
+	return sc.writeText(ctx, node, data)
+}
+
+// writeText - write text data to a node, returning the result of the write.
+// Every entry is validated before anything is written, and an error naming
+// the index of the first invalid entry, such as one with an offset in
+// milliseconds, is returned if any is invalid.
+func (sc *SnowthClient) writeText(ctx context.Context, node *SnowthNode,
+	data []TextData) (*WriteResult, error) {
+	for i, d := range data {
+		if err := d.validate(); err != nil {
+			return nil, errors.Wrapf(err, "invalid text data at index %d", i)
+		}
+	}
+	if sc.metricPrefix != "" {
+		data = append([]TextData(nil), data...)
+		for i := range data {
+			data[i].Metric = sc.metricName(data[i].Metric)
+		}
+	}
 	var (
 		buf = new(bytes.Buffer)
 		enc = json.NewEncoder(buf)
 	)
 	if err := enc.Encode(data); err != nil {
-		return errors.Wrap(err, "failed to encode TextData for write")
+		return nil, errors.Wrap(err, "failed to encode TextData for write")
 	}
-	err = sc.do(node, "POST", "/write/text", buf, nil, nil)
-	return
+	resp, err := sc.send(ctx, node, "POST", "/write/text", buf, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	result, err := newWriteResult(resp, len(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Rejected) > 0 {
+		return result, &PartialWriteError{Result: result}
+	}
+	return result, nil
 }
 
+// ReadTextValues - read text data from a node.  A window holding no data
+// returns no values, as an empty slice, without error, while a metric the
+// node does not know returns an error wrapping ErrMetricNotFound.
 func (sc *SnowthClient) ReadTextValues(
 	node *SnowthNode, start, end time.Time,
-	id, metric string) ([]TextValue, error) {
+	id, metric string, opts ...ReadOption) ([]TextValue, error) {
+	return sc.ReadTextValuesContext(context.Background(), node, start, end,
+		id, metric, opts...)
+}
+
+// ReadTextValuesContext - read text data from a node, as ReadTextValues
+// does, aborting the read when the context given is done
+func (sc *SnowthClient) ReadTextValuesContext(ctx context.Context,
+	node *SnowthNode, start, end time.Time,
+	id, metric string, opts ...ReadOption) ([]TextValue, error) {
 	var (
-		tvr = new(TextValueResponse)
-		err = sc.do(node, "GET", path.Join("/read",
-			strconv.FormatInt(start.Unix(), 10),
-			strconv.FormatInt(end.Unix(), 10),
-			id, metric), nil, tvr, decodeJSONFromResponse)
+		ro  = newReadOptions(opts)
+		tvr *TextValueResponse
 	)
+	err := sc.read(ctx, ro, MetricRef{ID: id, Metric: metric},
+		end, 0, func() (int, time.Time, error) {
+			tvr = &TextValueResponse{Data: []TextValue{}}
+			err := sc.doContext(ctx, node, "GET", path.Join("/read",
+				strconv.FormatInt(start.Unix(), 10),
+				strconv.FormatInt(end.Unix(), 10),
+				id, sc.metricName(metric)), nil, tvr, decodeJSONFromResponse)
+			if err == nil && ro.checkWindow {
+				d := tvr.Data
+				tvr.Data = d[:sc.trimToWindow(metric, start, end, len(d),
+					func(i int) time.Time { return d[i].Time },
+					func(dst, src int) { d[dst] = d[src] })]
+			}
+			if err != nil || len(tvr.Data) == 0 {
+				return 0, time.Time{}, err
+			}
+			return len(tvr.Data), tvr.Data[len(tvr.Data)-1].Time, nil
+		})
 
 	return tvr.Data, err
 }
 
+// ReadTextValuesFunc - read text data from a node, as ReadTextValues does,
+// but call the function given with each value as it is decoded from the
+// response, rather than building a slice of the values, so that reads of
+// wide windows use bounded memory.  Reading stops when the function returns
+// an error, which is returned by the read, unless it is ErrStopIteration,
+// which stops the read without error.
+func (sc *SnowthClient) ReadTextValuesFunc(
+	node *SnowthNode, start, end time.Time,
+	id, metric string, fn func(TextValue) error) error {
+	return sc.ReadTextValuesFuncContext(context.Background(), node, start,
+		end, id, metric, fn)
+}
+
+// ReadTextValuesFuncContext - read text data from a node, as ReadTextValuesFunc
+// does, aborting the read when the context given is done
+func (sc *SnowthClient) ReadTextValuesFuncContext(ctx context.Context,
+	node *SnowthNode, start, end time.Time, id, metric string,
+	fn func(TextValue) error) error {
+
+	decodeFunc := func(_ interface{}, reader io.Reader) error {
+		dec := json.NewDecoder(reader)
+		if _, err := dec.Token(); err != nil {
+			return errors.Wrap(err, "failed to decode text response")
+		}
+		for dec.More() {
+			var entry []interface{}
+			if err := dec.Decode(&entry); err != nil {
+				return errors.Wrap(err, "failed to decode text value")
+			}
+			if len(entry) < 2 {
+				return fmt.Errorf("text value should contain two entries, "+
+					"%d given", len(entry))
+			}
+			ts, ok := entry[0].(float64)
+			if !ok {
+				return fmt.Errorf("invalid text value time: %v", entry[0])
+			}
+			value, ok := entry[1].(string)
+			if !ok {
+				return fmt.Errorf("invalid text value: %v", entry[1])
+			}
+			if err := fn(TextValue{
+				Time:  time.Unix(int64(ts), 0),
+				Value: value,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err := sc.doContext(ctx, node, "GET", path.Join("/read",
+		strconv.FormatInt(start.Unix(), 10),
+		strconv.FormatInt(end.Unix(), 10),
+		id, sc.metricName(metric)), nil, fn, decodeFunc)
+	if errors.Cause(err) == ErrStopIteration {
+		return nil
+	}
+	return err
+}
+
 type TextValueResponse struct {
 	Data []TextValue
 }
@@ -67,10 +273,25 @@ type TextValue struct {
 	Value string
 }
 
-// TextData - representation of Text Data for data submission and retrieval
+// TextData - representation of Text Data for data submission and retrieval.
+// The offset is the time of the value in whole seconds since the epoch, as a
+// decimal string, which NewTextData formats from a time.
 type TextData struct {
 	Metric string `json:"metric"`
 	ID     string `json:"id"`
 	Offset string `json:"offset"`
 	Value  string `json:"value"`
 }
+
+// NewTextData - create text data for submission of a value of a metric at
+// the time given, with the offset formatted in seconds since the epoch.  The
+// offset does not depend on the location of the time, and any fraction of a
+// second is truncated.
+func NewTextData(id, metric string, t time.Time, value string) TextData {
+	return TextData{
+		Metric: metric,
+		ID:     id,
+		Offset: formatOffset(t),
+		Value:  value,
+	}
+}
@@ -2,7 +2,14 @@ package gosnowth
 
 import (
 	"encoding/json"
+	"net/http"
+	"path"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestTextValue(t *testing.T) {
@@ -14,3 +21,213 @@ func TestTextValue(t *testing.T) {
 		t.Error("error unmarshalling: ", err)
 	}
 }
+
+func TestWriteText(t *testing.T) {
+	var written []TextData
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/write/text", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&written))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	err := sc.WriteText(node,
+		TextData{Metric: "a", ID: "id", Offset: "1380000000", Value: "hello"},
+		TextData{Metric: "b", ID: "id", Offset: "1380000300", Value: "world"})
+	assert.NoError(t, err)
+	assert.Equal(t, []TextData{
+		{Metric: "a", ID: "id", Offset: "1380000000", Value: "hello"},
+		{Metric: "b", ID: "id", Offset: "1380000300", Value: "world"},
+	}, written, "all entries should be written to the node")
+
+	written = nil
+	err = sc.WriteText(node,
+		TextData{Metric: "a", ID: "id", Offset: "1380000000", Value: "hello"},
+		TextData{Metric: "b", ID: "id", Offset: "1380000300000",
+			Value: "world"})
+	if assert.Error(t, err, "offsets in milliseconds should be rejected") {
+		assert.Contains(t, err.Error(), "index 1")
+	}
+	assert.Nil(t, written, "nothing should be written")
+}
+
+func TestNewTextData(t *testing.T) {
+	at := time.Date(2013, 9, 24, 5, 20, 0, 500, time.FixedZone("x", 3600))
+	d := NewTextData("id", "metric", at, "hello")
+	assert.Equal(t, TextData{Metric: "metric", ID: "id",
+		Offset: "1379996400", Value: "hello"}, d,
+		"the offset should be in seconds, whatever the location")
+	assert.Equal(t, d, NewTextData("id", "metric", at.UTC(), "hello"))
+	assert.NoError(t, d.validate())
+
+	d.Offset = "1379996400000"
+	if assert.Error(t, d.validate()) {
+		assert.Contains(t, d.validate().Error(), "seconds",
+			"offsets in milliseconds should be rejected")
+	}
+}
+
+func TestWriteTextWithResult(t *testing.T) {
+	var body string
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	defer sc.Close()
+	data := []TextData{
+		{Metric: "a", ID: "id", Offset: "1380000000", Value: "hello"},
+		{Metric: "b", ID: "id", Offset: "1380000300", Value: "world"},
+	}
+
+	result, err := sc.WriteTextWithResult(node, data...)
+	if err != nil {
+		t.Fatal("error writing text: ", err)
+	}
+	assert.Equal(t, 2, result.Accepted,
+		"every entry should be accepted when the node reports nothing")
+	assert.Empty(t, result.Rejected)
+	assert.False(t, result.Time.IsZero(), "the response time should be kept")
+
+	body = `{"rejected":[{"index":1,"error":"invalid offset"}]}`
+	result, err = sc.WriteTextWithResult(node, data...)
+	var pwe *PartialWriteError
+	if !errors.As(err, &pwe) {
+		t.Fatal("expected a partial write error, got: ", err)
+	}
+	assert.Equal(t, result, pwe.Result)
+	assert.Equal(t, 1, result.Accepted)
+	assert.Equal(t, []RejectedRecord{{Index: 1, Error: "invalid offset"}},
+		result.Rejected)
+	assert.Equal(t, "1 records rejected, 1 accepted: record 1: "+
+		"invalid offset", sc.WriteText(node, data...).Error(),
+		"WriteText should report rejected entries")
+}
+
+func TestWriteTextRouted(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		written = make(map[string][][]TextData)
+		failing = "cccccccc-0000-0000-0000-000000000000"
+		addrs   []string
+	)
+	for _, id := range []string{
+		"aaaaaaaa-0000-0000-0000-000000000000",
+		"bbbbbbbb-0000-0000-0000-000000000000",
+		"cccccccc-0000-0000-0000-000000000000",
+	} {
+		id := id
+		ts := newRingNodeTestServer(id, 2,
+			func(w http.ResponseWriter, r *http.Request) {
+				if id == failing {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				var data []TextData
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&data))
+				mu.Lock()
+				written[id] = append(written[id], data)
+				mu.Unlock()
+			})
+		defer ts.Close()
+		addrs = append(addrs, ts.URL)
+	}
+	sc, err := NewSnowthClient(false, addrs...)
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	defer sc.Close()
+
+	err = sc.WriteTextRouted(
+		TextData{ID: ringTestUUID, Metric: "a", Offset: "1380000000",
+			Value: "1"},
+		TextData{ID: ringTestUUID, Metric: "b", Offset: "1380000000",
+			Value: "2"},
+		TextData{ID: ringTestUUID, Metric: "a", Offset: "1380000000",
+			Value: "3"},
+		TextData{ID: ringTestUUID, Metric: "c", Offset: "1380000000",
+			Value: "4"})
+	assert.Error(t, err, "the failed write should be reported")
+	assert.Equal(t, map[string][][]TextData{
+		"bbbbbbbb-0000-0000-0000-000000000000": {{
+			{ID: ringTestUUID, Metric: "a", Offset: "1380000000",
+				Value: "1"},
+			{ID: ringTestUUID, Metric: "a", Offset: "1380000000",
+				Value: "3"},
+		}},
+		"aaaaaaaa-0000-0000-0000-000000000000": {{
+			{ID: ringTestUUID, Metric: "b", Offset: "1380000000",
+				Value: "2"},
+		}},
+	}, written, "data should be batched by owner, despite the failure")
+}
+
+func TestReadTextValuesFunc(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/read/1380000000/1380000600/id/metric", r.URL.Path)
+		w.Write([]byte(`[[1380000000,"a"],[1380000300,"b"],` +
+			`[1380000600,"c"]]`))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	defer sc.Close()
+
+	var values []TextValue
+	err := sc.ReadTextValuesFunc(node, time.Unix(1380000000, 0),
+		time.Unix(1380000600, 0), "id", "metric",
+		func(v TextValue) error {
+			values = append(values, v)
+			if len(values) == 2 {
+				return ErrStopIteration
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatal("error reading text values: ", err)
+	}
+	assert.Equal(t, []TextValue{
+		{Time: time.Unix(1380000000, 0), Value: "a"},
+		{Time: time.Unix(1380000300, 0), Value: "b"},
+	}, values, "iteration should stop early")
+
+	failure := errors.New("failure")
+	err = sc.ReadTextValuesFunc(node, time.Unix(1380000000, 0),
+		time.Unix(1380000600, 0), "id", "metric",
+		func(v TextValue) error {
+			return failure
+		})
+	assert.Equal(t, failure, errors.Cause(err),
+		"callback errors should be returned")
+}
+
+func TestReadTextValuesNoData(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch path.Base(r.URL.Path) {
+		case "empty":
+			w.Write([]byte("[]"))
+		case "missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	defer sc.Close()
+	start, end := time.Unix(1380000000, 0), time.Unix(1380000060, 0)
+
+	values, err := sc.ReadTextValues(node, start, end, "id", "empty")
+	assert.NoError(t, err)
+	assert.Equal(t, []TextValue{}, values,
+		"a window with no data should return an empty slice")
+
+	_, err = sc.ReadTextValues(node, start, end, "id", "missing")
+	assert.True(t, errors.Is(err, ErrMetricNotFound),
+		"a missing metric should be reported as not found")
+
+	_, err = sc.ReadTextValues(node, start, end, "id", "broken")
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrMetricNotFound),
+		"other failures should not be reported as not found")
+}
@@ -1,6 +1,7 @@
 package gosnowth
 
 import (
+	"context"
 	"encoding/xml"
 	"path"
 
@@ -9,26 +10,115 @@ import (
 
 // GetTopologyInfo - Get the topology information from the node.
 func (sc *SnowthClient) GetTopologyInfo(node *SnowthNode) (topology *Topology, err error) {
+	return sc.GetTopologyInfoContext(context.Background(), node)
+}
+
+// GetTopologyInfoContext - get the topology information, as GetTopologyInfo
+// does, aborting the request when the context given is done
+func (sc *SnowthClient) GetTopologyInfoContext(ctx context.Context,
+	node *SnowthNode) (topology *Topology, err error) {
+	return sc.getTopologyContext(ctx, node, node.GetCurrentTopology())
+}
+
+// getTopology - get the topology with the hash given from the node, which
+// need not be the topology the node currently uses
+func (sc *SnowthClient) getTopology(node *SnowthNode, hash string) (topology *Topology, err error) {
+	return sc.getTopologyContext(context.Background(), node, hash)
+}
+
+// getTopologyContext - get the topology with the hash given from the node,
+// aborting the request when the context given is done
+func (sc *SnowthClient) getTopologyContext(ctx context.Context,
+	node *SnowthNode, hash string) (topology *Topology, err error) {
 	topology = new(Topology)
-	err = sc.do(node, "GET",
-		path.Join("/topology/xml", node.GetCurrentTopology()),
+	err = sc.doContext(ctx, node, "GET", path.Join("/topology/xml", hash),
 		nil, topology, decodeXMLFromResponse)
+	topology.Hash = hash
 	return
 }
 
+// CurrentTopology - get a copy of the topology last fetched by the client
+// in discovering nodes, when it was created or by ReloadTopology, without
+// making a request.  The topology includes its hash and its nodes, from
+// which the owners of metrics may be worked out.  ErrValueNotFound is
+// returned if no topology has been fetched.
+func (sc *SnowthClient) CurrentTopology() (*Topology, error) {
+	sc.topologyMu.Lock()
+	defer sc.topologyMu.Unlock()
+	if sc.topology == nil {
+		return nil, errors.Wrap(ErrValueNotFound,
+			"no topology has been discovered")
+	}
+	topology := *sc.topology
+	topology.Nodes = append([]TopologyNode(nil), sc.topology.Nodes...)
+	return &topology, nil
+}
+
+// ReloadTopology - re-fetch the topology after it has changed on the
+// cluster.  The current topology of each active node is refreshed from its
+// state, and the nodes of the new topologies are then discovered, updating
+// the addresses of known nodes and adding any new ones.  Snowth reloads its
+// own topology when a new one is activated, with ActivateTopology, so there
+// is no node-side reload to trigger here.
+func (sc *SnowthClient) ReloadTopology() error {
+	return sc.ReloadTopologyContext(context.Background())
+}
+
+// ReloadTopologyContext - re-fetch the topology after it has changed, as
+// ReloadTopology does, aborting the requests when the context given is done
+func (sc *SnowthClient) ReloadTopologyContext(ctx context.Context) error {
+	mErr := newMultiError()
+	for _, node := range sc.ListActiveNodes() {
+		state, err := sc.GetNodeStateContext(ctx, node)
+		if err != nil {
+			mErr.Add(errors.Wrap(err, "error getting node state"))
+			continue
+		}
+		if state.Current != node.GetCurrentTopology() {
+			sc.Logger.Infof("topology of node changed: %s -> %s",
+				node.GetURL().Host, state.Current)
+		}
+		node.setCurrentTopology(state.Current)
+	}
+	if err := sc.discoverNodesContext(ctx); err != nil {
+		mErr.Add(err)
+		return mErr
+	}
+	return nil
+}
+
 // LoadTopology - Load a new topology. Will not activate, just load and store.
 func (sc *SnowthClient) LoadTopology(hash string, topology *Topology, node *SnowthNode) (err error) {
+	return sc.LoadTopologyContext(context.Background(), hash, topology,
+		node)
+}
+
+// LoadTopologyContext - load a new topology, as LoadTopology does, aborting the
+// request when the context given is done
+func (sc *SnowthClient) LoadTopologyContext(ctx context.Context,
+	hash string, topology *Topology, node *SnowthNode) (err error) {
+
 	reqBody, err := encodeXML(topology)
 	if err != nil {
 		return errors.Wrap(err, "failed to encode request data")
 	}
-	err = sc.do(node, "POST", path.Join("/topology", hash), reqBody, nil, nil)
+	err = sc.doContext(ctx, node, "POST", path.Join("/topology", hash),
+		reqBody, nil, nil)
 	return
 }
 
 // ActivateTopology - Switch to a new topology.  THIS IS DANGEROUS.
 func (sc *SnowthClient) ActivateTopology(hash string, node *SnowthNode) (err error) {
-	err = sc.do(node, "GET", path.Join("/activate", hash), nil, nil, nil)
+	return sc.ActivateTopologyContext(context.Background(), hash, node)
+}
+
+// ActivateTopologyContext - switch to a new topology, as ActivateTopology does,
+// aborting the request when the context given is done
+func (sc *SnowthClient) ActivateTopologyContext(ctx context.Context,
+	hash string, node *SnowthNode) (err error) {
+
+	err = sc.doContext(ctx, node, "GET", path.Join("/activate", hash), nil,
+		nil, nil)
 	return
 }
 
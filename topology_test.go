@@ -4,9 +4,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"encoding/xml"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -82,3 +87,59 @@ func TestTopologyXMLSerialization(t *testing.T) {
 
 	assert.Equal(t, 4, strings.Count(buf.String(), "id="), "should have 4 nodes")
 }
+
+func TestReloadTopology(t *testing.T) {
+	const (
+		oldHash = "294cbd39999c2270964029691e8bc5e231a867d525ccba62181dc8988ff218dc"
+		newHash = "e7a3f7d6a1c5b19a2a5e0f3f0c9b8f1e2d3c4b5a69788796a5b4c3d2e1f0a9b8"
+	)
+	var (
+		current = oldHash
+		host    string
+		port    string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/state":
+				w.Write([]byte(strings.Replace(stateTestData, oldHash,
+					current, 1)))
+			case "/topology/xml/" + newHash:
+				fmt.Fprintf(w, `<nodes n="2">`+
+					`<node id="bb6f7162-4828-11df-bab8-6bac200dcc2a" `+
+					`address="%s" port="8112" apiport="%s" weight="32"/>`+
+					`<node id="8c2fc7b8-c569-402d-a393-db433fb267aa" `+
+					`address="10.0.0.2" port="8112" apiport="8112" `+
+					`weight="32"/></nodes>`, host, port)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	host, port, _ = net.SplitHostPort(node.GetURL().Host)
+
+	_, err := sc.CurrentTopology()
+	assert.Equal(t, ErrValueNotFound, errors.Cause(err),
+		"no topology should be known before discovery")
+
+	current = newHash
+	if err := sc.ReloadTopology(); err != nil {
+		t.Fatal("error reloading topology: ", err)
+	}
+	assert.Equal(t, newHash, node.GetCurrentTopology(),
+		"node should have the changed topology")
+	assert.Equal(t, 2, len(sc.ListActiveNodes()),
+		"nodes of the changed topology should be discovered")
+
+	topology, err := sc.CurrentTopology()
+	if err != nil {
+		t.Fatal("error getting current topology: ", err)
+	}
+	assert.Equal(t, newHash, topology.Hash)
+	assert.Equal(t, 2, len(topology.Nodes))
+	topology.Nodes[0].Address = "changed"
+	topology, _ = sc.CurrentTopology()
+	assert.Equal(t, host, topology.Nodes[0].Address,
+		"the topology returned should be a copy")
+}
@@ -1,14 +1,24 @@
 package gosnowth
 
 import (
+	"context"
 	"encoding/xml"
 	"path"
 )
 
 // GetTopoRingInfo - Get the toporing information from the node.
 func (sc *SnowthClient) GetTopoRingInfo(hash string, node *SnowthNode) (toporing *TopoRing, err error) {
+	return sc.GetTopoRingInfoContext(context.Background(), hash, node)
+}
+
+// GetTopoRingInfoContext - get the topology ring information, as
+// GetTopoRingInfo does, aborting the request when the context given is done
+func (sc *SnowthClient) GetTopoRingInfoContext(ctx context.Context,
+	hash string, node *SnowthNode) (toporing *TopoRing, err error) {
+
 	toporing = new(TopoRing)
-	err = sc.do(node, "GET", path.Join("/toporing/xml", hash), nil, toporing, decodeXMLFromResponse)
+	err = sc.doContext(ctx, node, "GET", path.Join("/toporing/xml", hash),
+		nil, toporing, decodeXMLFromResponse)
 	return
 }
 
@@ -0,0 +1,238 @@
+package gosnowth
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultHTTPTimeout - the timeout of requests made by the client's default
+// http client, so that a hung node cannot block a request forever
+const defaultHTTPTimeout = 10 * time.Second
+
+// defaultMaxIdleConnsPerHost - the number of idle connections to each node
+// kept for reuse by the client's default http client.  This is well above
+// the two of http.DefaultTransport, which forces concurrent requests to a
+// node to dial new connections once more than two are in flight.
+const defaultMaxIdleConnsPerHost = 32
+
+// WithHTTPClient - make requests with the http client given, in place of the
+// client's default http client, which has a timeout of ten seconds.  This
+// allows the transport, including TLS, proxy and connection pool settings,
+// and the timeout of requests to be configured.  The options configuring
+// the default http client, WithLocalAddr, WithMaxConnAge and
+// WithMaxIdleConnsPerHost, have no effect on a client given, and connections
+// made by it are not counted by OpenConnections.  A client given should set
+// MaxIdleConnsPerHost on its transport when making many concurrent requests
+// to each node.
+func WithHTTPClient(c *http.Client) ClientOption {
+	return func(sc *SnowthClient) error {
+		if c == nil {
+			return errors.New("nil http client")
+		}
+		sc.c = c
+		return nil
+	}
+}
+
+// newTransport - create the transport used by the client's default http
+// client.  The settings mirror those of http.DefaultTransport, with dialing
+// instrumented so that open connections can be tracked per node, and made
+// from the local address given, if any.  Up to maxIdlePerHost idle
+// connections are kept for reuse to each node, with no limit across nodes.
+// Connections are recycled once older than the maximum age given, if it is
+// positive.
+func newTransport(ct *connTracker, localAddr net.Addr,
+	maxConnAge time.Duration, maxIdlePerHost int) http.RoundTripper {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		LocalAddr: localAddr,
+	}
+	t := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           ct.wrapDial(dialer.DialContext),
+		MaxIdleConnsPerHost:   maxIdlePerHost,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if maxConnAge <= 0 {
+		return t
+	}
+	return &maxAgeTransport{rt: t, maxAge: maxConnAge}
+}
+
+// WithMaxConnAge - recycle connections to nodes once they have been open for
+// the duration given, rather than keeping them alive indefinitely.  Some
+// load balancers and proxies silently drop long lived connections, so that
+// requests sent on them fail.  A connection found to be older than the
+// maximum age is used for one last request, which asks for the connection
+// to be closed once it completes.  The age only applies to the client's
+// default http client.
+func WithMaxConnAge(maxAge time.Duration) ClientOption {
+	return func(sc *SnowthClient) error {
+		sc.maxConnAge = maxAge
+		return nil
+	}
+}
+
+// maxAgeTransport - a round tripper which closes the connections of the
+// transport it wraps once they are older than a maximum age
+type maxAgeTransport struct {
+	rt     http.RoundTripper
+	maxAge time.Duration
+}
+
+// RoundTrip - perform a request, closing the connection it is sent on after
+// the response if the connection has exceeded the maximum age
+func (mt *maxAgeTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	var req *http.Request
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			// the connection is obtained before the request is written, so
+			// the request may still ask for it to be closed
+			if connAge(info.Conn) > mt.maxAge {
+				req.Close = true
+			}
+		},
+	}
+	req = r.Clone(httptrace.WithClientTrace(r.Context(), trace))
+	return mt.rt.RoundTrip(req)
+}
+
+// CloseIdleConnections - close the idle connections of the transport it
+// wraps, so that closing the http client using the transport releases them
+func (mt *maxAgeTransport) CloseIdleConnections() {
+	if c, ok := mt.rt.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+// connAge - how long ago a connection was dialed, or zero for connections
+// which were not dialed by a connection tracker
+func connAge(conn net.Conn) time.Duration {
+	if nc, ok := conn.(interface{ NetConn() net.Conn }); ok {
+		// unwrap tls connections
+		conn = nc.NetConn()
+	}
+	if tc, ok := conn.(*trackedConn); ok {
+		return time.Since(tc.dialed)
+	}
+	return 0
+}
+
+// WithMaxIdleConnsPerHost - keep up to the number of idle connections given
+// to each node for reuse, in place of the default of 32.  Concurrent
+// requests to a node beyond the number of idle connections kept dial new
+// connections, which are closed once their requests complete.  The limit
+// only applies to the client's default http client.
+func WithMaxIdleConnsPerHost(n int) ClientOption {
+	return func(sc *SnowthClient) error {
+		if n <= 0 {
+			return errors.New("max idle connections per host must be positive")
+		}
+		sc.maxIdleConnsPerHost = n
+		return nil
+	}
+}
+
+// WithLocalAddr - make requests from the local address given, an IP address
+// with an optional port, so that on hosts with multiple interfaces requests
+// egress from the chosen interface.  The address only applies to the
+// client's default http client.
+func WithLocalAddr(addr string) ClientOption {
+	return func(sc *SnowthClient) error {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			addr = net.JoinHostPort(addr, "0")
+		}
+		tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
+		if err != nil {
+			return errors.Wrap(err, "invalid local address")
+		}
+		sc.localAddr = tcpAddr
+		return nil
+	}
+}
+
+// connTracker - keeps count of the open connections dialed to each address
+type connTracker struct {
+	mu    sync.Mutex
+	conns map[string]int
+}
+
+// newConnTracker - create a new connection tracker with no connections
+func newConnTracker() *connTracker {
+	return &connTracker{
+		conns: make(map[string]int),
+	}
+}
+
+// wrapDial - wrap a dial function so that the connections it opens are
+// counted until they are closed
+func (ct *connTracker) wrapDial(
+	dial func(ctx context.Context, network, addr string) (net.Conn, error),
+) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		ct.mu.Lock()
+		ct.conns[addr]++
+		ct.mu.Unlock()
+		return &trackedConn{Conn: conn, ct: ct, addr: addr,
+			dialed: time.Now()}, nil
+	}
+}
+
+// release - stop counting a connection to an address
+func (ct *connTracker) release(addr string) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	ct.conns[addr]--
+	if ct.conns[addr] <= 0 {
+		delete(ct.conns, addr)
+	}
+}
+
+// snapshot - copy the current connection counts
+func (ct *connTracker) snapshot() map[string]int {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	result := make(map[string]int, len(ct.conns))
+	for addr, n := range ct.conns {
+		result[addr] = n
+	}
+	return result
+}
+
+// trackedConn - a connection which is released from its tracker on close
+type trackedConn struct {
+	net.Conn
+	ct     *connTracker
+	addr   string
+	dialed time.Time
+	once   sync.Once
+}
+
+// Close - close the connection, releasing it from the tracker
+func (tc *trackedConn) Close() error {
+	tc.once.Do(func() {
+		tc.ct.release(tc.addr)
+	})
+	return tc.Conn.Close()
+}
+
+// OpenConnections - return a snapshot of the number of connections the
+// client currently holds open to each node, keyed by the host and port of
+// the node.  This includes connections serving requests as well as idle
+// connections held in the pool for reuse.
+func (sc *SnowthClient) OpenConnections() map[string]int {
+	return sc.conns.snapshot()
+}
@@ -0,0 +1,238 @@
+package gosnowth
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenConnections(t *testing.T) {
+	var (
+		arrived = make(chan struct{})
+		release = make(chan struct{})
+	)
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+		w.Write([]byte("[]"))
+	})
+	defer ts.Close()
+	sc, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithMaxIdleConnsPerHost(2))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	node := sc.ListActiveNodes()[0]
+
+	const requests = 4
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := sc.ReadNNTValues(node, time.Now(), time.Now(), 60,
+				"count", "id", "metric")
+			if err != nil {
+				t.Error("error reading nnt values: ", err)
+			}
+		}()
+	}
+	for i := 0; i < requests; i++ {
+		<-arrived
+	}
+	assert.Equal(t, requests, sc.OpenConnections()[node.GetURL().Host],
+		"each concurrent request should hold a connection")
+
+	close(release)
+	wg.Wait()
+	deadline := time.Now().Add(time.Second)
+	for sc.OpenConnections()[node.GetURL().Host] > 2 &&
+		time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, 2, sc.OpenConnections()[node.GetURL().Host],
+		"only idle pooled connections should remain open")
+}
+
+func TestWithMaxIdleConnsPerHost(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {})
+	defer ts.Close()
+	_, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithMaxIdleConnsPerHost(0))
+	assert.Error(t, err, "the limit should be positive")
+
+	sc, _ := newTestClient(t, ts)
+	defer sc.Close()
+	transport := sc.c.(*http.Client).Transport.(*http.Transport)
+	assert.Equal(t, defaultMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost,
+		"the default http client should keep more than two idle connections")
+}
+
+// BenchmarkConcurrentReads - read concurrently from five nodes, comparing
+// the default idle connection limit with that of http.DefaultTransport
+func BenchmarkConcurrentReads(b *testing.B) {
+	var addrs []string
+	for i := 0; i < 5; i++ {
+		ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("[[1380000000,1]]"))
+		})
+		defer ts.Close()
+		addrs = append(addrs, ts.URL)
+	}
+	for _, bc := range []struct {
+		name    string
+		maxIdle int
+	}{
+		{"default", defaultMaxIdleConnsPerHost},
+		{"http_default", http.DefaultMaxIdleConnsPerHost},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			sc, err := NewSnowthClientWithOptions(false, addrs,
+				WithMaxIdleConnsPerHost(bc.maxIdle))
+			if err != nil {
+				b.Fatal("failed to create client: ", err)
+			}
+			defer sc.Close()
+			nodes := sc.ListActiveNodes()
+			b.SetParallelism(16)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					_, err := sc.ReadNNTValues(nodes[i%len(nodes)],
+						time.Unix(1380000000, 0), time.Unix(1380000060, 0),
+						60, "count", "id", "metric")
+					if err != nil {
+						b.Error("error reading nnt values: ", err)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
+
+func TestWithLocalAddr(t *testing.T) {
+	var remote string
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		remote = r.RemoteAddr
+		w.Write([]byte("[]"))
+	})
+	defer ts.Close()
+
+	sc, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithLocalAddr("127.0.0.1"))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	_, err = sc.ReadNNTValues(sc.ListActiveNodes()[0], time.Now(), time.Now(),
+		60, "count", "id", "metric")
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	host, _, err := net.SplitHostPort(remote)
+	if err != nil {
+		t.Fatal("invalid remote address: ", err)
+	}
+	assert.Equal(t, "127.0.0.1", host, "requests should use the local address")
+
+	_, err = NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithLocalAddr("not an address"))
+	assert.Error(t, err, "an invalid local address should fail")
+}
+
+func TestWithMaxConnAge(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		remotes []string
+	)
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		remotes = append(remotes, r.RemoteAddr)
+		mu.Unlock()
+		w.Write([]byte("[]"))
+	})
+	defer ts.Close()
+	sc, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithMaxConnAge(50*time.Millisecond))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	node := sc.ListActiveNodes()[0]
+
+	read := func() {
+		_, err := sc.ReadNNTValues(node, time.Now(), time.Now(), 60,
+			"count", "id", "metric")
+		if err != nil {
+			t.Fatal("error reading nnt values: ", err)
+		}
+	}
+	read()
+	read()
+	time.Sleep(100 * time.Millisecond)
+	read()
+	read()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, remotes[0], remotes[1],
+		"connections should be reused before the maximum age")
+	assert.Equal(t, remotes[1], remotes[2],
+		"an expired connection should serve one last request")
+	assert.NotEqual(t, remotes[2], remotes[3],
+		"an expired connection should be recycled")
+
+	sc.Close()
+	deadline := time.Now().Add(time.Second)
+	for sc.OpenConnections()[node.GetURL().Host] > 0 &&
+		time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, 0, sc.OpenConnections()[node.GetURL().Host],
+		"closing the client should close idle connections")
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	})
+	defer ts.Close()
+
+	var requests int
+	c := &http.Client{
+		Timeout: time.Second,
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			requests++
+			return http.DefaultTransport.RoundTrip(r)
+		}),
+	}
+	sc, err := NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithHTTPClient(c))
+	if err != nil {
+		t.Fatal("failed to create client: ", err)
+	}
+	before := requests
+	_, err = sc.ReadNNTValues(sc.ListActiveNodes()[0], time.Now(), time.Now(),
+		60, "count", "id", "metric")
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.True(t, before > 0, "bootstrap should use the client given")
+	assert.Equal(t, before+1, requests, "reads should use the client given")
+
+	_, err = NewSnowthClientWithOptions(false, []string{ts.URL},
+		WithHTTPClient(nil))
+	assert.Error(t, err, "a nil http client should be rejected")
+}
+
+// roundTripFunc - a function implementing http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip - call the function
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,244 @@
+package gosnowth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Metric - data of any of the types the client writes, which are NNTData,
+// NumericData, TextData and HistogramData, or pointers to them, for writing
+// data of mixed types together with Write
+type Metric interface {
+	validate() error
+}
+
+// validateMetricRef - check that data names the metric it is for
+func validateMetricRef(id, metric string) error {
+	if id == "" {
+		return errors.New("no id")
+	}
+	if metric == "" {
+		return errors.New("no metric name")
+	}
+	return nil
+}
+
+// validate - check that NNT data may be written
+func (d NNTData) validate() error {
+	return validateMetricRef(d.ID, d.Metric)
+}
+
+// validate - check that text data may be written
+func (d TextData) validate() error {
+	if err := validateMetricRef(d.ID, d.Metric); err != nil {
+		return err
+	}
+	return validateOffset(d.Offset)
+}
+
+// maxOffset - the largest offset in seconds accepted for data written, in
+// the year 5138, beyond which an offset is taken to be in milliseconds
+const maxOffset = 99999999999
+
+// formatOffset - format a time as an offset of data written, in whole
+// seconds since the epoch
+func formatOffset(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+// validateOffset - check that an offset of data written is a whole number
+// of seconds since the epoch, rejecting offsets in milliseconds
+func validateOffset(offset string) error {
+	v, err := strconv.ParseInt(offset, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid offset: %q", offset)
+	}
+	if v > maxOffset {
+		return fmt.Errorf("invalid offset: %q, offsets should be in seconds",
+			offset)
+	}
+	return nil
+}
+
+// validate - check that histogram data may be written
+func (d HistogramData) validate() error {
+	if err := validateMetricRef(d.ID, d.Metric); err != nil {
+		return err
+	}
+	if d.Bins != nil {
+		_, err := histogramFromBins(d.Bins)
+		return err
+	}
+	if d.Histogram == nil {
+		return errors.New("no histogram")
+	}
+	return nil
+}
+
+// metricValue - the data a metric points to, or nil if it points to none
+func metricValue(m Metric) Metric {
+	switch v := m.(type) {
+	case *NNTData:
+		if v != nil {
+			return *v
+		}
+		return nil
+	case *NumericData:
+		if v != nil {
+			return *v
+		}
+		return nil
+	case *TextData:
+		if v != nil {
+			return *v
+		}
+		return nil
+	case *HistogramData:
+		if v != nil {
+			return *v
+		}
+		return nil
+	}
+	return m
+}
+
+// Write - write data of mixed types to a node, such as a stream of numeric
+// and text metrics.  Every entry is validated before anything is written,
+// and an error naming the index of the first invalid entry is returned if
+// any is invalid.  Snowth accepts each type of data at its own endpoint, so
+// the data is written with one request per type present, as with WriteNNT,
+// WriteNumeric, WriteText and WriteHistogram.  Every type is written even if
+// others fail, and the failures are returned together.
+func (sc *SnowthClient) Write(node *SnowthNode, metrics ...Metric) error {
+	return sc.WriteContext(context.Background(), node, metrics...)
+}
+
+// WriteContext - write data of mixed types to a node, as Write does, aborting
+// the writes when the context given is done
+func (sc *SnowthClient) WriteContext(ctx context.Context,
+	node *SnowthNode, metrics ...Metric) error {
+
+	var (
+		nnt     []NNTData
+		numeric []NumericData
+		text    []TextData
+		hist    []HistogramData
+	)
+	for i, m := range metrics {
+		m = metricValue(m)
+		if m == nil {
+			return fmt.Errorf("invalid metric at index %d: no data", i)
+		}
+		if err := m.validate(); err != nil {
+			return errors.Wrapf(err, "invalid metric at index %d", i)
+		}
+		switch v := m.(type) {
+		case NNTData:
+			nnt = append(nnt, v)
+		case NumericData:
+			numeric = append(numeric, v)
+		case TextData:
+			text = append(text, v)
+		case HistogramData:
+			hist = append(hist, v)
+		}
+	}
+
+	mErr := newMultiError()
+	if len(nnt) > 0 {
+		mErr.Add(errors.Wrap(sc.WriteNNTContext(ctx, node, nnt...),
+			"failed to write nnt data"))
+	}
+	if len(numeric) > 0 {
+		mErr.Add(errors.Wrap(sc.WriteNumericContext(ctx, node,
+			numeric...), "failed to write numeric data"))
+	}
+	if len(text) > 0 {
+		mErr.Add(errors.Wrap(sc.WriteTextContext(ctx, node, text...),
+			"failed to write text data"))
+	}
+	if len(hist) > 0 {
+		mErr.Add(errors.Wrap(sc.WriteHistogramContext(ctx, node,
+			hist...), "failed to write histogram data"))
+	}
+	if mErr.HasError() {
+		return mErr
+	}
+	return nil
+}
+
+// WriteResult - what a node reports of a write it received: the number of
+// records it accepted, those it rejected, and the time of the response, from
+// its Date header, or zero if it sent none.  Nodes which report nothing in
+// the body of their response accepted every record written.
+type WriteResult struct {
+	Accepted int
+	Rejected []RejectedRecord
+	Time     time.Time
+}
+
+// RejectedRecord - a record of a write rejected by a node, by its index in
+// the data written, with the reason given by the node
+type RejectedRecord struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// writeResponse - the JSON form of a write response body enumerating the
+// records accepted and rejected
+type writeResponse struct {
+	Accepted *int             `json:"accepted"`
+	Rejected []RejectedRecord `json:"rejected"`
+}
+
+// newWriteResult - the result of a write of the number of records given,
+// from the response of the node, which may be nil in dry-run mode
+func newWriteResult(resp *http.Response, records int) (*WriteResult, error) {
+	result := &WriteResult{Accepted: records}
+	if resp == nil {
+		return result, nil
+	}
+	if t, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		result.Time = t
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read write response")
+	}
+	var wr writeResponse
+	if len(bytes.TrimSpace(b)) == 0 || json.Unmarshal(b, &wr) != nil {
+		return result, nil
+	}
+	result.Rejected = wr.Rejected
+	if wr.Accepted != nil {
+		result.Accepted = *wr.Accepted
+	} else {
+		result.Accepted = records - len(wr.Rejected)
+	}
+	return result, nil
+}
+
+// PartialWriteError - returned for a write which a node accepted only some
+// of the records of, with the result of the write listing those rejected
+type PartialWriteError struct {
+	Result *WriteResult
+}
+
+// Error - the description of the error
+func (e *PartialWriteError) Error() string {
+	msg := fmt.Sprintf("%d records rejected, %d accepted",
+		len(e.Result.Rejected), e.Result.Accepted)
+	if len(e.Result.Rejected) > 0 {
+		r := e.Result.Rejected[0]
+		msg += fmt.Sprintf(": record %d: %s", r.Index, r.Error)
+	}
+	return msg
+}
@@ -0,0 +1,50 @@
+package gosnowth
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, r.URL.Path)
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	err := sc.Write(node,
+		NNTData{ID: "id", Metric: "a", Offset: 1380000000, Count: 1},
+		&TextData{ID: "id", Metric: "b", Offset: "1380000000", Value: "x"},
+		HistogramData{ID: "id", Metric: "c", Offset: 1380000000,
+			Period: 60, Bins: []HistogramBin{{Value: 1, Count: 2}}},
+		TextData{ID: "id", Metric: "d", Offset: "1380000060", Value: "y"},
+		NumericData{ID: "id", Metric: "e", Offset: "1380000000", Value: 1})
+	assert.NoError(t, err)
+	sort.Strings(paths)
+	assert.Equal(t, []string{"/histogram/write", "/raw", "/write/nnt",
+		"/write/text"}, paths, "each type should be written in one request")
+
+	paths = nil
+	err = sc.Write(node,
+		NNTData{ID: "id", Metric: "a", Offset: 1380000000, Count: 1},
+		TextData{ID: "id", Metric: "b", Offset: "soon"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "index 1")
+	}
+	assert.Empty(t, paths, "nothing should be written for an invalid batch")
+
+	err = sc.Write(node, (*NNTData)(nil))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "index 0")
+	}
+}
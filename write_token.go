@@ -0,0 +1,100 @@
+package gosnowth
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// WriteToken - records the position of data written, so that a later read
+// may wait until the writes are readable, using the WithWriteToken read
+// option.  Snowth provides no api reporting the position to which a node
+// has applied writes, so the position kept for each metric is the latest
+// offset written to it, and waiting falls back to retrying the read, as
+// when ingest lag delays data, until a value at that offset is returned.
+// A token may be shared by concurrent writes and reads.
+type WriteToken struct {
+	mu      sync.Mutex
+	offsets map[MetricRef]time.Time
+}
+
+// NewWriteToken - create an empty write token
+func NewWriteToken() *WriteToken {
+	return &WriteToken{offsets: make(map[MetricRef]time.Time)}
+}
+
+// add - record a write of a metric at an offset
+func (wt *WriteToken) add(id, metric string, offset time.Time) {
+	ref := MetricRef{ID: id, Metric: metric}
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+	if offset.After(wt.offsets[ref]) {
+		wt.offsets[ref] = offset
+	}
+}
+
+// offset - the latest offset recorded for a metric, if any
+func (wt *WriteToken) offset(ref MetricRef) (time.Time, bool) {
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+	offset, ok := wt.offsets[ref]
+	return offset, ok
+}
+
+// AddNNT - record NNT data written, returning the token
+func (wt *WriteToken) AddNNT(data ...NNTData) *WriteToken {
+	for _, d := range data {
+		wt.add(d.ID, d.Metric, time.Unix(d.Offset, 0))
+	}
+	return wt
+}
+
+// AddText - record text data written, returning the token.  Data with an
+// offset which is not an integer number of seconds is not recorded.
+func (wt *WriteToken) AddText(data ...TextData) *WriteToken {
+	for _, d := range data {
+		if offset, err := strconv.ParseInt(d.Offset, 10, 64); err == nil {
+			wt.add(d.ID, d.Metric, time.Unix(offset, 0))
+		}
+	}
+	return wt
+}
+
+// WriteNNTWithToken - write NNT data to a node, as WriteNNT does, returning
+// a write token recording the data written, to be given to reads of the data
+// with the WithWriteToken option
+func (sc *SnowthClient) WriteNNTWithToken(node *SnowthNode,
+	data ...NNTData) (*WriteToken, error) {
+	return sc.WriteNNTWithTokenContext(context.Background(), node, data...)
+}
+
+// WriteNNTWithTokenContext - write NNT data to a node, as WriteNNTWithToken
+// does, aborting the write when the context given is done
+func (sc *SnowthClient) WriteNNTWithTokenContext(ctx context.Context,
+	node *SnowthNode, data ...NNTData) (*WriteToken, error) {
+
+	if err := sc.WriteNNTContext(ctx, node, data...); err != nil {
+		return nil, err
+	}
+	return NewWriteToken().AddNNT(data...), nil
+}
+
+// WriteTextWithToken - write text data to a node, as WriteText does,
+// returning a write token recording the data written, to be given to reads
+// of the data with the WithWriteToken option
+func (sc *SnowthClient) WriteTextWithToken(node *SnowthNode,
+	data ...TextData) (*WriteToken, error) {
+	return sc.WriteTextWithTokenContext(context.Background(), node, data...)
+}
+
+// WriteTextWithTokenContext - write text data to a node, as WriteTextWithToken
+// does, aborting the write when the context given is done
+func (sc *SnowthClient) WriteTextWithTokenContext(ctx context.Context,
+	node *SnowthNode, data ...TextData) (*WriteToken, error) {
+
+	if err := sc.WriteTextContext(ctx, node, data...); err != nil {
+		return nil, err
+	}
+	return NewWriteToken().AddText(data...), nil
+}
@@ -0,0 +1,120 @@
+package gosnowth
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithWriteToken(t *testing.T) {
+	var (
+		reads   int
+		written = time.Unix(1380000120, 0)
+	)
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		reads++
+		if reads < 3 {
+			w.Write([]byte("[[1380000000,1]]"))
+			return
+		}
+		fmt.Fprintf(w, "[[1380000000,1],[%d,2]]", written.Unix())
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+
+	token := NewWriteToken().AddNNT(NNTData{
+		ID: "id", Metric: "metric", Offset: written.Unix(), Count: 1,
+	})
+	data, err := sc.ReadNNTValues(node, time.Unix(1380000000, 0),
+		time.Unix(1380000600, 0), 60, "count", "id", "metric",
+		WithWriteToken(token, time.Second))
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, 3, reads, "read should wait for the write")
+	assert.Equal(t, 2, len(data), "read should include the write")
+
+	// metrics not in the token are not waited for
+	reads = 0
+	data, err = sc.ReadNNTValues(node, time.Unix(1380000000, 0),
+		time.Unix(1380000600, 0), 60, "count", "id", "other",
+		WithWriteToken(token, time.Second))
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, 1, reads, "other metrics should not be retried")
+	assert.Equal(t, 1, len(data))
+}
+
+func TestWriteTokenAddText(t *testing.T) {
+	token := NewWriteToken().AddText(
+		TextData{ID: "id", Metric: "metric", Offset: "1380000000"},
+		TextData{ID: "id", Metric: "metric", Offset: "1380000060"},
+		TextData{ID: "id", Metric: "bad", Offset: "bad"},
+	)
+	assert.Equal(t, map[MetricRef]time.Time{
+		{ID: "id", Metric: "metric"}: time.Unix(1380000060, 0),
+	}, token.offsets)
+}
+
+func TestWriteNNTWithToken(t *testing.T) {
+	var reads int
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/write/nnt" {
+			return
+		}
+		reads++
+		if reads < 3 {
+			w.Write([]byte("[[1380000000,1]]"))
+			return
+		}
+		// values are labelled with the start of their period
+		w.Write([]byte("[[1380000000,1],[1380000120,2]]"))
+	})
+	defer ts.Close()
+	sc, node := newTestClient(t, ts)
+	defer sc.Close()
+
+	// the write falls within the period starting at 1380000120
+	token, err := sc.WriteNNTWithToken(node, NNTData{
+		ID: "id", Metric: "metric", Offset: 1380000150, Count: 1,
+	})
+	if err != nil {
+		t.Fatal("error writing nnt data: ", err)
+	}
+	start := time.Now()
+	data, err := sc.ReadNNTValues(node, time.Unix(1380000000, 0),
+		time.Unix(1380000600, 0), 60, "count", "id", "metric",
+		WithWriteToken(token, 5*time.Second))
+	if err != nil {
+		t.Fatal("error reading nnt values: ", err)
+	}
+	assert.Equal(t, 3, reads, "read should wait for the write")
+	assert.Equal(t, 2, len(data), "read should include the write")
+	assert.True(t, time.Since(start) < 5*time.Second,
+		"read should stop once the period of the write is read")
+}
+
+func TestWriteTokenConcurrent(t *testing.T) {
+	var (
+		token = NewWriteToken()
+		wg    sync.WaitGroup
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			token.AddNNT(NNTData{ID: "id", Metric: "metric",
+				Offset: 1380000000 + int64(i)})
+			token.offset(MetricRef{ID: "id", Metric: "metric"})
+		}(i)
+	}
+	wg.Wait()
+	offset, ok := token.offset(MetricRef{ID: "id", Metric: "metric"})
+	assert.True(t, ok)
+	assert.Equal(t, time.Unix(1380000009, 0), offset)
+}